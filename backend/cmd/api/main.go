@@ -7,10 +7,14 @@ import (
 	"io"
 	"justping/backend/internal/database"
 	"justping/backend/internal/handlers"
+	"justping/backend/internal/notifier"
 	"justping/backend/internal/renderer"
+	"justping/backend/internal/scheduler"
 	"log"
 	"net/http"
 	"os"
+	"time"
+	_ "time/tzdata" // Digest timezones; the runtime image has no zoneinfo
 
 	"github.com/joho/godotenv"
 )
@@ -46,16 +50,63 @@ func main() {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 	defer database.Disconnect()
+	if err := database.EnsureIndexes(); err != nil {
+		log.Fatalf("Failed to create MongoDB indexes: %v", err)
+	}
+
+	// Start the check scheduler (CHECK_BACKEND: changedetection or native)
+	if err := scheduler.Start(os.Getenv("CHECK_BACKEND")); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
+	defer scheduler.Stop()
+
+	// Give monitors from before webhook tokens a token and a tokenized
+	// webhook URL on their watch
+	go scheduler.BackfillWebhookTokens()
+
+	// Deliver alerts over each monitor's notification channels
+	notifier.Start()
+	defer notifier.Stop()
+
+	// Keep monitors and changedetection.io watches in sync
+	reconcileInterval := time.Hour
+	if v := os.Getenv("RECONCILE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid RECONCILE_INTERVAL: %v", err)
+		}
+		reconcileInterval = d
+	}
+	// Watches left behind by deleted monitors are deleted unless turned off
+	scheduler.StartReconciler(reconcileInterval, os.Getenv("RECONCILE_DELETE_ORPHANS") != "false")
 
 	// Monitor API routes
 	http.HandleFunc("/api/monitors", handlers.ListMonitors)
 	http.HandleFunc("/api/monitors/create", handlers.CreateMonitor)
+	http.HandleFunc("/api/monitors/pause", handlers.PauseMonitors)
+	http.HandleFunc("/api/monitors/resume", handlers.ResumeMonitors)
 	http.HandleFunc("/api/monitors/", handlers.MonitorByID)
 
 	// Alert API routes
 	http.HandleFunc("/api/webhook", handlers.HandleWebhook)
+	http.HandleFunc("/api/webhook/", handlers.HandleWebhook)
 	http.HandleFunc("/api/alerts", handlers.ListAlerts)
 	http.HandleFunc("/api/alerts/mark-checked", handlers.MarkAlertsAsChecked)
+	http.HandleFunc("/api/alerts/bulk", handlers.BulkAlerts)
+	http.HandleFunc("/api/alerts/stream", handlers.StreamAlerts)
+	http.HandleFunc("/api/alerts/", handlers.AlertByID)
+	http.HandleFunc("/api/channels", handlers.Channels)
+	http.HandleFunc("/api/channels/telegram/link", handlers.LinkTelegram)
+	http.HandleFunc("/api/channels/", handlers.ChannelByID)
+	http.HandleFunc("/api/deliveries", handlers.Deliveries)
+	http.HandleFunc("/api/deliveries/", handlers.DeliveryByID)
+	http.HandleFunc("/api/settings/notifications", handlers.NotificationSettings)
+	http.HandleFunc("/api/escalation-policies", handlers.EscalationPolicies)
+	http.HandleFunc("/api/escalation-policies/", handlers.EscalationPolicyByID)
+
+	// Admin routes
+	http.HandleFunc("/api/admin/reconcile", handlers.HandleReconcile)
+	http.HandleFunc("/api/admin/webhook-rejections", handlers.WebhookRejections)
 
 	// Existing watch route
 	http.HandleFunc("/api/watch", handleWatch)
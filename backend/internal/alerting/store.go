@@ -0,0 +1,85 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Outcome says what Store did with an alert
+type Outcome string
+
+const (
+	Created   Outcome = "created"   // Stored as a new alert
+	Collapsed Outcome = "collapsed" // Folded into a recent alert for the same monitor
+	Duplicate Outcome = "duplicate" // Already recorded under the same idempotency key
+)
+
+// Store saves alert unless its idempotency key was seen before. When the
+// monitor has an alert cooldown and its latest unchecked alert last changed
+// within that window, the change is folded into that alert instead: its
+// count goes up and lastChangeAt moves forward, so a flapping page produces
+// one alert. Stored alerts are published to the user's open streams. It
+// returns the alert as stored and what happened to it.
+func Store(ctx context.Context, m models.Monitor, alert *models.Alert, key string) (*models.Alert, Outcome, error) {
+	alertsCollection := database.GetAlertsCollection()
+
+	seen, err := alertsCollection.CountDocuments(ctx, bson.M{"idempotencyKeys": key}, options.Count().SetLimit(1))
+	if err != nil {
+		return nil, "", err
+	}
+	if seen > 0 {
+		return nil, Duplicate, nil
+	}
+
+	if m.AlertCooldown > 0 {
+		since := alert.ReceivedAt.Add(-time.Duration(m.AlertCooldown) * time.Minute)
+		var existing models.Alert
+		err := alertsCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"monitorId":    m.ID,
+				"checked":      false,
+				"archived":     bson.M{"$ne": true},
+				"lastChangeAt": bson.M{"$gte": since},
+			},
+			bson.M{
+				"$inc":      bson.M{"count": 1},
+				"$set":      bson.M{"lastChangeAt": alert.ReceivedAt, "lastSnapshotTs": alert.SnapshotTimestamp},
+				"$addToSet": bson.M{"idempotencyKeys": key},
+			},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "lastChangeAt", Value: -1}}).
+				SetReturnDocument(options.After),
+		).Decode(&existing)
+		switch {
+		case err == nil:
+			publish(EventAlertUpdate, existing)
+			return &existing, Collapsed, nil
+		case mongo.IsDuplicateKeyError(err):
+			return nil, Duplicate, nil
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			return nil, "", err
+		}
+	}
+
+	lastChangeAt := alert.ReceivedAt
+	alert.Count = 1
+	alert.LastChangeAt = &lastChangeAt
+	alert.IdempotencyKeys = []string{key}
+
+	if _, err := alertsCollection.InsertOne(ctx, alert); err != nil {
+		// A concurrent delivery with the same key won the race
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, Duplicate, nil
+		}
+		return nil, "", err
+	}
+	publish(EventAlert, *alert)
+	return alert, Created, nil
+}
@@ -0,0 +1,112 @@
+package alerting
+
+import (
+	"fmt"
+	"justping/backend/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event types sent to subscribers
+const (
+	EventAlert       = "alert"        // A new alert was stored
+	EventAlertUpdate = "alert-update" // Another change was folded into an alert
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before it is disconnected and has to resume with its last event ID
+const subscriberBuffer = 32
+
+// Event is an alert change pushed to the user's open streams
+type Event struct {
+	ID    string // See EventID
+	Type  string
+	Alert models.Alert
+}
+
+// Subscribers live in this process only, so every instance serving
+// streams must also be the one storing the alerts
+var (
+	subscribers   = map[string]map[chan Event]struct{}{}
+	subscribersMu sync.Mutex
+)
+
+// Subscribe registers a stream for userID. The channel is closed when the
+// returned cancel func is called or when the subscriber falls too far
+// behind, in which case the client should reconnect and resume.
+func Subscribe(userID string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	subscribersMu.Lock()
+	if subscribers[userID] == nil {
+		subscribers[userID] = map[chan Event]struct{}{}
+	}
+	subscribers[userID][ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	cancel := func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		unsubscribe(userID, ch)
+	}
+	return ch, cancel
+}
+
+// unsubscribe must be called with subscribersMu held
+func unsubscribe(userID string, ch chan Event) {
+	if _, ok := subscribers[userID][ch]; !ok {
+		return
+	}
+	delete(subscribers[userID], ch)
+	if len(subscribers[userID]) == 0 {
+		delete(subscribers, userID)
+	}
+	close(ch)
+}
+
+// publish sends an event for alert to every stream of its user
+func publish(eventType string, alert models.Alert) {
+	event := Event{ID: EventID(alert), Type: eventType, Alert: alert}
+
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+
+	for ch := range subscribers[alert.UserID] {
+		select {
+		case ch <- event:
+		default:
+			unsubscribe(alert.UserID, ch)
+		}
+	}
+}
+
+// EventID orders events by the alert's last change: "<unix ms>-<alert id>".
+// Clients send it back as Last-Event-ID to resume after a reconnect.
+func EventID(alert models.Alert) string {
+	changedAt := alert.ReceivedAt
+	if alert.LastChangeAt != nil {
+		changedAt = *alert.LastChangeAt
+	}
+	return fmt.Sprintf("%d-%s", changedAt.UnixMilli(), alert.ID.Hex())
+}
+
+// ParseEventID reverses EventID
+func ParseEventID(id string) (time.Time, primitive.ObjectID, error) {
+	millis, hex, ok := strings.Cut(id, "-")
+	if !ok {
+		return time.Time{}, primitive.NilObjectID, fmt.Errorf("malformed event ID %q", id)
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, fmt.Errorf("malformed event ID %q", id)
+	}
+	alertID, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, fmt.Errorf("malformed event ID %q", id)
+	}
+	return time.UnixMilli(ms), alertID, nil
+}
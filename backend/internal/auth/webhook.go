@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers used for signed webhook deliveries
+const (
+	WebhookSignatureHeader = "X-JustPing-Signature" // "sha256=<hex>"
+	WebhookTimestampHeader = "X-JustPing-Timestamp" // unix seconds
+)
+
+// WebhookTolerance is how far a signed timestamp may be from our clock
+const WebhookTolerance = 5 * time.Minute
+
+// Errors returned when verifying a webhook. Their text doubles as the
+// reason recorded for rejected attempts.
+var (
+	ErrWebhookStale     = errors.New("stale_timestamp")
+	ErrWebhookSignature = errors.New("invalid_signature")
+	ErrWebhookReplay    = errors.New("replayed")
+	ErrWebhookToken     = errors.New("invalid_token")
+)
+
+// seenSignatures remembers accepted signatures until they fall outside
+// WebhookTolerance, so a captured request cannot be sent again
+var (
+	seenSignatures   = map[string]time.Time{}
+	seenSignaturesMu sync.Mutex
+)
+
+// NewWebhookToken returns a random per-monitor token for webhook URLs
+func NewWebhookToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// VerifyWebhookToken compares a token from a webhook URL with the monitor's
+func VerifyWebhookToken(got, want string) error {
+	if got == "" || want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		return ErrWebhookToken
+	}
+	return nil
+}
+
+// SignWebhook returns the signature header value for body sent at timestamp
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks an HMAC-SHA256 signature over
+// "<timestamp>.<body>", rejecting timestamps outside WebhookTolerance and
+// signatures that were already accepted once.
+func VerifyWebhookSignature(secret, signature, timestamp string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookStale
+	}
+	sent := time.Unix(ts, 0)
+	if sent.Before(now.Add(-WebhookTolerance)) || sent.After(now.Add(WebhookTolerance)) {
+		return ErrWebhookStale
+	}
+
+	expected := SignWebhook(secret, ts, body)
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		return ErrWebhookSignature
+	}
+
+	seenSignaturesMu.Lock()
+	defer seenSignaturesMu.Unlock()
+
+	for sig, expires := range seenSignatures {
+		if now.After(expires) {
+			delete(seenSignatures, sig)
+		}
+	}
+	if _, seen := seenSignatures[expected]; seen {
+		return ErrWebhookReplay
+	}
+	seenSignatures[expected] = sent.Add(WebhookTolerance)
+
+	return nil
+}
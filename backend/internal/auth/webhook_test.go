@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	got := SignWebhook("secret", 1700000000, []byte(`{"a":1}`))
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got != want {
+		t.Errorf("SignWebhook() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"watch_uuid":"abc"}`)
+	sign := func(ts int64) string { return SignWebhook("secret", ts, body) }
+	unix := now.Unix()
+
+	// Each accepted case uses its own timestamp, as a signature is only
+	// accepted once
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      string
+		want      error
+	}{
+		{"valid", "secret", sign(unix), strconv.FormatInt(unix, 10), string(body), nil},
+		{"upper-case hex", "secret", "sha256=" + strings.ToUpper(sign(unix - 1)[7:]), strconv.FormatInt(unix-1, 10), string(body), nil},
+		{"edge of tolerance", "secret", sign(unix - 300), strconv.FormatInt(unix-300, 10), string(body), nil},
+		{"too old", "secret", sign(unix - 301), strconv.FormatInt(unix-301, 10), string(body), ErrWebhookStale},
+		{"too new", "secret", sign(unix + 301), strconv.FormatInt(unix+301, 10), string(body), ErrWebhookStale},
+		{"bad timestamp", "secret", sign(unix), "yesterday", string(body), ErrWebhookStale},
+		{"wrong secret", "other", sign(unix - 2), strconv.FormatInt(unix-2, 10), string(body), ErrWebhookSignature},
+		{"tampered body", "secret", sign(unix - 3), strconv.FormatInt(unix-3, 10), `{"watch_uuid":"xyz"}`, ErrWebhookSignature},
+		{"timestamp not signed", "secret", sign(unix - 4), strconv.FormatInt(unix-5, 10), string(body), ErrWebhookSignature},
+		{"missing signature", "secret", "", strconv.FormatInt(unix, 10), string(body), ErrWebhookSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.secret, tt.signature, tt.timestamp, []byte(tt.body), now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyWebhookSignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookSignatureReplay(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	body := []byte(`{"watch_uuid":"replay"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	signature := SignWebhook("secret", now.Unix(), body)
+
+	if err := VerifyWebhookSignature("secret", signature, ts, body, now); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	if err := VerifyWebhookSignature("secret", signature, ts, body, now.Add(time.Minute)); !errors.Is(err, ErrWebhookReplay) {
+		t.Errorf("second delivery = %v, want ErrWebhookReplay", err)
+	}
+}
+
+func TestVerifyWebhookToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want string
+		wantErr   bool
+	}{
+		{"match", "abc123", "abc123", false},
+		{"mismatch", "abc124", "abc123", true},
+		{"empty token", "", "abc123", true},
+		{"monitor without token", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyWebhookToken(tt.got, tt.want); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyWebhookToken(%q, %q) = %v, wantErr %v", tt.got, tt.want, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package changedetection
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"justping/backend/internal/models"
 	"net/http"
+	"os"
+	"strings"
 )
 
 // Client is a client for the changedetection.io API
@@ -31,8 +35,43 @@ type CreateWatchRequest struct {
 	TimeBetweenCheck   *TimeBetweenCheck `json:"time_between_check,omitempty"`
 	Paused             bool              `json:"paused,omitempty"`
 	NotificationMuted  bool              `json:"notification_muted,omitempty"`
+	IncludeFilters     []string          `json:"include_filters,omitempty"` // CSS or "xpath:" selectors
+	NotificationURLs   []string          `json:"notification_urls,omitempty"`
+	NotificationBody   string            `json:"notification_body,omitempty"`
+	NotificationFormat string            `json:"notification_format,omitempty"`
 }
 
+// UpdateWatchRequest represents the fields changed on an existing watch.
+// Unset fields are left as they are on changedetection.io; pointers to
+// empty values clear a field.
+type UpdateWatchRequest struct {
+	URL                string            `json:"url,omitempty"`
+	Title              string            `json:"title,omitempty"`
+	TimeBetweenCheck   *TimeBetweenCheck `json:"time_between_check,omitempty"`
+	Paused             *bool             `json:"paused,omitempty"`
+	NotificationMuted  *bool             `json:"notification_muted,omitempty"`
+	IncludeFilters     *[]string         `json:"include_filters,omitempty"`
+	NotificationURLs   *[]string         `json:"notification_urls,omitempty"`
+	NotificationBody   *string           `json:"notification_body,omitempty"`
+	NotificationFormat *string           `json:"notification_format,omitempty"`
+}
+
+// Watch is a watch as listed by changedetection.io
+type Watch struct {
+	UUID              string `json:"uuid"`
+	URL               string `json:"url"`
+	Title             string `json:"title,omitempty"`
+	Paused            bool   `json:"paused"`
+	NotificationMuted bool   `json:"notification_muted"`
+	LastChecked       int64  `json:"last_checked,omitempty"`
+	LastChanged       int64  `json:"last_changed,omitempty"`
+
+	NotificationURLs []string `json:"notification_urls,omitempty"` // Only set by GetWatch
+}
+
+// ErrWatchNotFound is returned when changedetection.io has no watch with the given UUID
+var ErrWatchNotFound = errors.New("watch not found on changedetection.io")
+
 // NewClient creates a new changedetection.io client
 func NewClient(baseURL, apiKey string) *Client {
 	return &Client{
@@ -41,6 +80,31 @@ func NewClient(baseURL, apiKey string) *Client {
 	}
 }
 
+// NewClientFromEnv creates a client from CHANGEDETECTION_BASE_URL and
+// CHANGEDETECTION_API_KEY
+func NewClientFromEnv() *Client {
+	baseURL := os.Getenv("CHANGEDETECTION_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:5000"
+	}
+	return NewClient(baseURL, os.Getenv("CHANGEDETECTION_API_KEY"))
+}
+
+// MapSelectorToIncludeFilters converts a monitor selector to changedetection.io
+// include filters. CSS and XPath starting with "/" or "xpath:" pass through
+// unchanged; a parenthesised XPath such as "(//li)[1]" gets the "xpath:" prefix
+// changedetection.io needs to recognise it.
+func MapSelectorToIncludeFilters(selector string) []string {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return nil
+	}
+	if strings.HasPrefix(selector, "(") {
+		selector = "xpath:" + selector
+	}
+	return []string{selector}
+}
+
 // MapFrequencyToTimeBetweenCheck converts our Frequency model to changedetection.io format
 func MapFrequencyToTimeBetweenCheck(freq models.Frequency) *TimeBetweenCheck {
 	tbc := &TimeBetweenCheck{}
@@ -99,7 +163,75 @@ func (c *Client) CreateWatch(req CreateWatchRequest) (string, error) {
 	return result.UUID, nil
 }
 
-// DeleteWatch deletes a watch from changedetection.io
+// UpdateWatch updates an existing watch on changedetection.io
+func (c *Client) UpdateWatch(uuid string, req UpdateWatchRequest) error {
+	url := fmt.Sprintf("%s/api/v1/watch/%s", c.BaseURL, uuid)
+	
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	
+	httpReq, err := http.NewRequest("PUT", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.APIKey)
+	
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("changedetection.io API error: %d - %s", resp.StatusCode, string(body))
+	}
+	
+	return nil
+}
+
+// SetPaused pauses or unpauses a watch on changedetection.io.
+// Returns ErrWatchNotFound if the watch does not exist.
+func (c *Client) SetPaused(uuid string, paused bool) error {
+	state := "unpaused"
+	if paused {
+		state = "paused"
+	}
+	url := fmt.Sprintf("%s/api/v1/watch/%s?paused=%s", c.BaseURL, uuid, state)
+	
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	httpReq.Header.Set("x-api-key", c.APIKey)
+	
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrWatchNotFound
+	}
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("changedetection.io API error: %d - %s", resp.StatusCode, string(body))
+	}
+	
+	return nil
+}
+
+// DeleteWatch deletes a watch from changedetection.io.
+// Returns ErrWatchNotFound if the watch does not exist.
 func (c *Client) DeleteWatch(uuid string) error {
 	url := fmt.Sprintf("%s/api/v1/watch/%s", c.BaseURL, uuid)
 	
@@ -117,6 +249,10 @@ func (c *Client) DeleteWatch(uuid string) error {
 	}
 	defer resp.Body.Close()
 	
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrWatchNotFound
+	}
+	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("changedetection.io API error: %d - %s", resp.StatusCode, string(body))
@@ -124,3 +260,108 @@ func (c *Client) DeleteWatch(uuid string) error {
 	
 	return nil
 }
+
+// GetWatch fetches a single watch with all its settings.
+// Returns ErrWatchNotFound if the watch does not exist.
+func (c *Client) GetWatch(uuid string) (*Watch, error) {
+	url := fmt.Sprintf("%s/api/v1/watch/%s", c.BaseURL, uuid)
+	
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	httpReq.Header.Set("x-api-key", c.APIKey)
+	
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrWatchNotFound
+	}
+	
+	body, _ := io.ReadAll(resp.Body)
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("changedetection.io API error: %d - %s", resp.StatusCode, string(body))
+	}
+	
+	var watch Watch
+	if err := json.Unmarshal(body, &watch); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	watch.UUID = uuid
+	
+	return &watch, nil
+}
+
+// ListWatches returns every watch on changedetection.io keyed by UUID
+func (c *Client) ListWatches() (map[string]Watch, error) {
+	url := fmt.Sprintf("%s/api/v1/watch", c.BaseURL)
+	
+	httpReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	httpReq.Header.Set("x-api-key", c.APIKey)
+	
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	body, _ := io.ReadAll(resp.Body)
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("changedetection.io API error: %d - %s", resp.StatusCode, string(body))
+	}
+	
+	var watches map[string]Watch
+	if err := json.Unmarshal(body, &watches); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	
+	// The list is keyed by UUID; make sure each entry carries it too
+	for uuid, watch := range watches {
+		watch.UUID = uuid
+		watches[uuid] = watch
+	}
+	
+	return watches, nil
+}
+
+// GetSnapshot fetches the text snapshot of a watch at timestamp.
+// Pass "latest" for the most recent snapshot. The request is cancelled
+// with ctx.
+func (c *Client) GetSnapshot(ctx context.Context, uuid, timestamp string) (string, error) {
+	url := fmt.Sprintf("%s/api/v1/watch/%s/history/%s", c.BaseURL, uuid, timestamp)
+	
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	httpReq.Header.Set("x-api-key", c.APIKey)
+	
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	body, _ := io.ReadAll(resp.Body)
+	
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("changedetection.io API error: %d - %s", resp.StatusCode, string(body))
+	}
+	
+	return string(body), nil
+}
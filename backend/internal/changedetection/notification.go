@@ -0,0 +1,172 @@
+package changedetection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NotificationBody is the notification template attached to every watch.
+// Apprise's json:// target posts it as the "message" field of its own JSON
+// envelope; HandleWebhook unwraps it again. diff_url names the snapshot the
+// notification is for, which keys retried deliveries (see SnapshotTimestamp).
+const NotificationBody = `{
+"watch_uuid": {{ watch_uuid|tojson }},
+"watch_url": {{ watch_url|tojson }},
+"watch_title": {{ watch_title|tojson }},
+"diff": {{ diff|tojson }},
+"diff_added": {{ diff_added|tojson }},
+"diff_removed": {{ diff_removed|tojson }},
+"triggered_text": {{ triggered_text|tojson }},
+"current_snapshot": {{ current_snapshot|tojson }},
+"preview_url": {{ preview_url|tojson }},
+"diff_url": {{ diff_url|tojson }}
+}`
+
+// ErrNoPublicBaseURL is returned when PUBLIC_BASE_URL is not set, so watches
+// cannot be told where to deliver their notifications
+var ErrNoPublicBaseURL = errors.New("PUBLIC_BASE_URL is not set; changedetection.io needs it to reach the webhook")
+
+// CheckPublicBaseURL reports whether PUBLIC_BASE_URL is set and usable as
+// the base of webhook notification URLs
+func CheckPublicBaseURL() error {
+	publicBaseURL := os.Getenv("PUBLIC_BASE_URL")
+	if publicBaseURL == "" {
+		return ErrNoPublicBaseURL
+	}
+	_, err := WebhookNotificationURL(publicBaseURL, "")
+	return err
+}
+
+// WebhookNotificationURL returns the Apprise URL that delivers a watch's
+// notifications to our /api/webhook/<token> endpoint. publicBaseURL is how
+// changedetection.io reaches this backend, e.g. http://backend:3002.
+func WebhookNotificationURL(publicBaseURL, token string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(publicBaseURL, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid public base URL: %w", err)
+	}
+
+	// Apprise uses json:// for plain HTTP and jsons:// for HTTPS
+	var scheme string
+	switch u.Scheme {
+	case "http":
+		scheme = "json"
+	case "https":
+		scheme = "jsons"
+	default:
+		return "", fmt.Errorf("invalid public base URL %q: scheme must be http or https", publicBaseURL)
+	}
+
+	return fmt.Sprintf("%s://%s%s/api/webhook/%s", scheme, u.Host, u.Path, url.PathEscape(token)), nil
+}
+
+// IsWebhookNotificationURL reports whether notificationURL delivers to this
+// backend's webhook, which marks a watch as created by JustPing
+func IsWebhookNotificationURL(notificationURL string) bool {
+	publicBaseURL := os.Getenv("PUBLIC_BASE_URL")
+	if publicBaseURL == "" {
+		return false
+	}
+	prefix, err := WebhookNotificationURL(publicBaseURL, "")
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(notificationURL, prefix)
+}
+
+// WebhookNotification returns the notification settings for a watch whose
+// monitor has the given webhook token. It returns nil when the monitor has
+// no token, and ErrNoPublicBaseURL when PUBLIC_BASE_URL is not set.
+func WebhookNotification(token string) ([]string, error) {
+	publicBaseURL := os.Getenv("PUBLIC_BASE_URL")
+	if publicBaseURL == "" {
+		return nil, ErrNoPublicBaseURL
+	}
+	if token == "" {
+		return nil, nil
+	}
+
+	notificationURL, err := WebhookNotificationURL(publicBaseURL, token)
+	if err != nil {
+		return nil, err
+	}
+	return []string{notificationURL}, nil
+}
+
+// Notification is a webhook delivery rendered from NotificationBody
+type Notification struct {
+	WatchUUID       string `json:"watch_uuid"`
+	WatchURL        string `json:"watch_url"`
+	WatchTitle      string `json:"watch_title"`
+	Diff            string `json:"diff"`
+	DiffAdded       string `json:"diff_added"`
+	DiffRemoved     string `json:"diff_removed"`
+	TriggeredText   string `json:"triggered_text"`
+	CurrentSnapshot string `json:"current_snapshot"`
+	PreviewURL      string `json:"preview_url"`
+	DiffURL         string `json:"diff_url"`
+}
+
+// ParseNotification decodes a webhook body, unwrapping the JSON envelope
+// Apprise's json:// target puts around NotificationBody. It also returns
+// the unwrapped JSON, which is what should be kept as the raw payload.
+func ParseNotification(body []byte) (*Notification, []byte, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, nil, err
+	}
+	if _, ok := envelope["watch_uuid"]; !ok {
+		var message string
+		if err := json.Unmarshal(envelope["message"], &message); err == nil && json.Valid([]byte(message)) {
+			body = []byte(message)
+		}
+	}
+
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, nil, err
+	}
+	return &n, body, nil
+}
+
+// SnapshotTimestamp returns the timestamp of the snapshot the notification
+// is about, from the to_version parameter changedetection.io puts on
+// diff_url. ok is false when diff_url does not carry one.
+func (n *Notification) SnapshotTimestamp() (ts int64, ok bool) {
+	u, err := url.Parse(n.DiffURL)
+	if err != nil {
+		return 0, false
+	}
+	ts, err = strconv.ParseInt(u.Query().Get("to_version"), 10, 64)
+	if err != nil || ts <= 0 {
+		return 0, false
+	}
+	return ts, true
+}
+
+// AddedLines returns the lines of DiffAdded without the "(added) " marker
+// changedetection.io puts in front of each
+func (n *Notification) AddedLines() []string {
+	return diffLines(n.DiffAdded, "(added)")
+}
+
+// RemovedLines returns the lines of DiffRemoved without their "(removed) " marker
+func (n *Notification) RemovedLines() []string {
+	return diffLines(n.DiffRemoved, "(removed)")
+}
+
+func diffLines(text, marker string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), marker))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the API relies on. Creating an index
+// that already exists is a no-op, so this is safe to run on every start.
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		GetAlertsCollection(): {
+			// Alert listing: newest first per user, _id breaks ties for cursors
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "receivedAt", Value: -1}, {Key: "_id", Value: -1}}},
+			// Alert stream replay after a reconnect
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "lastChangeAt", Value: 1}, {Key: "_id", Value: 1}}},
+			// Cascading deletes and per-monitor filters
+			{Keys: bson.D{{Key: "monitorId", Value: 1}}},
+			// Reconciler lookup of the watches alerts came from
+			{Keys: bson.D{{Key: "payload.watch_uuid", Value: 1}}},
+			// Webhook deduplication; alerts stored before keys existed have none
+			{
+				Keys:    bson.D{{Key: "idempotencyKeys", Value: 1}},
+				Options: options.Index().SetUnique(true).SetSparse(true),
+			},
+		},
+		GetMonitorsCollection(): {
+			// Webhook and reconciler lookups by watch
+			{Keys: bson.D{{Key: "changeDetectionUuid", Value: 1}}},
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+			// Escalation worker and policy deletes; most monitors have none
+			{
+				Keys:    bson.D{{Key: "escalationPolicyId", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			},
+		},
+		GetDeliveriesCollection(): {
+			{Keys: bson.D{{Key: "alertId", Value: 1}}},
+			// Dispatcher sweep for pending and stalled deliveries
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "claimedAt", Value: 1}}},
+			// Retries that are due
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+			// Delivery log of one channel and the dead-letter list
+			{Keys: bson.D{{Key: "channelId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "status", Value: 1}, {Key: "updatedAt", Value: -1}}},
+		},
+		GetChannelsCollection(): {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "type", Value: 1}}},
+		},
+		GetDigestsCollection(): {
+			// One digest per user, channel and scheduled time, so a run is
+			// never sent twice
+			{
+				Keys: bson.D{
+					{Key: "userId", Value: 1},
+					{Key: "frequency", Value: 1},
+					{Key: "channel", Value: 1},
+					{Key: "channelId", Value: 1},
+					{Key: "scheduledFor", Value: -1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			// Scheduler pass over pending and due digests
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		},
+		GetEscalationPoliciesCollection(): {
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+		},
+		GetSnapshotsCollection(): {
+			{Keys: bson.D{{Key: "monitorId", Value: 1}, {Key: "timestamp", Value: -1}}},
+		},
+	}
+
+	for collection, specs := range indexes {
+		names, err := collection.Indexes().CreateMany(ctx, specs, options.CreateIndexes())
+		if err != nil {
+			return fmt.Errorf("create indexes on %s: %w", collection.Name(), err)
+		}
+		log.Printf("Ensured indexes on %s: %v", collection.Name(), names)
+	}
+
+	return nil
+}
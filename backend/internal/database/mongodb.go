@@ -38,6 +38,36 @@ func GetAlertsCollection() *mongo.Collection {
 	return client.Database("justping").Collection("alerts")
 }
 
+func GetSnapshotsCollection() *mongo.Collection {
+	return client.Database("justping").Collection("snapshots")
+}
+
+func GetDeliveriesCollection() *mongo.Collection {
+	return client.Database("justping").Collection("deliveries")
+}
+
+func GetChannelsCollection() *mongo.Collection {
+	return client.Database("justping").Collection("channels")
+}
+
+func GetSettingsCollection() *mongo.Collection {
+	return client.Database("justping").Collection("notification_settings")
+}
+
+func GetDigestsCollection() *mongo.Collection {
+	return client.Database("justping").Collection("digests")
+}
+
+func GetEscalationPoliciesCollection() *mongo.Collection {
+	return client.Database("justping").Collection("escalation_policies")
+}
+
+// GetUsersCollection returns the users managed by the auth service, which
+// shares this database
+func GetUsersCollection() *mongo.Collection {
+	return client.Database("justping").Collection("user")
+}
+
 func Disconnect() error {
 	if client == nil {
 		return nil
@@ -0,0 +1,213 @@
+package diff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxEditDistance bounds the work done by the Myers search. Inputs that
+// differ by more than this many edits are reported as a full replacement.
+const maxEditDistance = 4000
+
+// Kind is the type of a diff operation
+type Kind string
+
+const (
+	Equal  Kind = "equal"
+	Insert Kind = "insert"
+	Delete Kind = "delete"
+)
+
+// Op is a single run of equal, inserted or deleted text
+type Op struct {
+	Kind Kind   `json:"op"`
+	Text string `json:"text"`
+}
+
+// reWordToken splits text into words and the whitespace between them, so
+// joining the tokens gives back the original text
+var reWordToken = regexp.MustCompile(`\s+|[^\s]+`)
+
+// Lines diffs a and b line by line. Each Op holds one line without its
+// trailing newline.
+func Lines(a, b string) []Op {
+	return tokens(splitLines(a), splitLines(b))
+}
+
+// Words diffs a and b word by word. Adjacent tokens of the same kind are
+// merged, so each Op holds a run of words including their whitespace.
+func Words(a, b string) []Op {
+	ops := tokens(reWordToken.FindAllString(a, -1), reWordToken.FindAllString(b, -1))
+	return merge(ops)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// merge joins consecutive ops of the same kind
+func merge(ops []Op) []Op {
+	var merged []Op
+	for _, op := range ops {
+		if n := len(merged); n > 0 && merged[n-1].Kind == op.Kind {
+			merged[n-1].Text += op.Text
+			continue
+		}
+		merged = append(merged, op)
+	}
+	return merged
+}
+
+// tokens returns the shortest edit script turning a into b, one Op per token.
+// Most page changes are small, so trimming the common ends first keeps the
+// Myers search tiny.
+func tokens(a, b []string) []Op {
+	return trimCommon(a, b, myers)
+}
+
+// trimCommon strips the common prefix and suffix of a and b, diffs what is
+// left with diffMiddle and returns the script for the whole input
+func trimCommon(a, b []string, diffMiddle func(a, b []string) []Op) []Op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Op
+	for _, t := range a[:prefix] {
+		ops = append(ops, Op{Kind: Equal, Text: t})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		ops = append(ops, Op{Kind: Equal, Text: t})
+	}
+	return ops
+}
+
+// myers implements the linear-space variant of Eugene Myers' O(ND)
+// difference algorithm: it finds the middle snake of an optimal edit script
+// and recurses on both halves, so memory stays proportional to the input
+// rather than to the square of the edit distance.
+func myers(a, b []string) []Op {
+	if len(a) == 0 || len(b) == 0 {
+		return replaceAll(a, b)
+	}
+	x, y, u, v, ok := middleSnake(a, b, (maxEditDistance+1)/2)
+	if !ok {
+		return replaceAll(a, b)
+	}
+	return split(a, b, x, y, u, v)
+}
+
+// script returns an edit script for a and b of any size
+func script(a, b []string) []Op {
+	return trimCommon(a, b, unboundedScript)
+}
+
+// unboundedScript splits a and b at their middle snake without an edit
+// limit. a and b have no common prefix or suffix.
+func unboundedScript(a, b []string) []Op {
+	// One side is empty: the rest is all inserted or all deleted
+	if len(a) == 0 || len(b) == 0 {
+		return replaceAll(a, b)
+	}
+	x, y, u, v, _ := middleSnake(a, b, -1)
+	return split(a, b, x, y, u, v)
+}
+
+// split joins the scripts of the halves before and after the middle snake
+// from (x, y) to (u, v)
+func split(a, b []string, x, y, u, v int) []Op {
+	ops := script(a[:x], b[:y])
+	for _, t := range a[x:u] {
+		ops = append(ops, Op{Kind: Equal, Text: t})
+	}
+	return append(ops, script(a[u:], b[v:])...)
+}
+
+// middleSnake returns the middle snake of a shortest edit script for a and
+// b, a run of matches from (x, y) to (u, v) with half of the script's edits
+// on either side. a and b must not both be empty. It gives up, returning
+// ok false, once more than limit steps are needed in each direction; a
+// negative limit never gives up.
+func middleSnake(a, b []string, limit int) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[k] is the furthest x reached on diagonal k = x - y from the
+	// start; backward[k] is the same for the reversed inputs, measured
+	// from the end
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+
+	for d := 0; d <= maxD; d++ {
+		if limit >= 0 && d > limit {
+			return 0, 0, 0, 0, false
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			// Reaching the backward path of step d-1 on this diagonal
+			// finds the middle snake
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 && x+backward[offset+kb] >= n {
+				return startX, startY, x, y, true
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			if kf := delta - k; !odd && kf >= -d && kf <= d && x+forward[offset+kf] >= n {
+				return n - x, m - y, n - startX, m - startY, true
+			}
+		}
+	}
+	// Not reached: the paths always meet within maxD steps
+	return 0, 0, n, m, true
+}
+
+// replaceAll is the fallback script: delete everything in a, insert all of b
+func replaceAll(a, b []string) []Op {
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, t := range a {
+		ops = append(ops, Op{Kind: Delete, Text: t})
+	}
+	for _, t := range b {
+		ops = append(ops, Op{Kind: Insert, Text: t})
+	}
+	return ops
+}
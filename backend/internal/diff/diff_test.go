@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Op
+	}{
+		{"both empty", "", "", nil},
+		{"identical", "a\nb\n", "a\nb\n", []Op{{Equal, "a"}, {Equal, "b"}}},
+		{"insert into empty", "", "a\nb", []Op{{Insert, "a"}, {Insert, "b"}}},
+		{"delete everything", "a\nb", "", []Op{{Delete, "a"}, {Delete, "b"}}},
+		{
+			"changed line",
+			"a\nb\nc", "a\nx\nc",
+			[]Op{{Equal, "a"}, {Delete, "b"}, {Insert, "x"}, {Equal, "c"}},
+		},
+		{
+			"inserted line",
+			"a\nc", "a\nb\nc",
+			[]Op{{Equal, "a"}, {Insert, "b"}, {Equal, "c"}},
+		},
+		{
+			"trailing newline ignored",
+			"a\nb\n", "a\nb",
+			[]Op{{Equal, "a"}, {Equal, "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := Words("the quick fox", "the slow fox")
+	want := []Op{{Equal, "the "}, {Delete, "quick"}, {Insert, "slow"}, {Equal, " fox"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %v, want %v", got, want)
+	}
+}
+
+// TestTokensShortest checks random inputs against a quadratic LCS: the
+// script must rebuild both sides and use the fewest possible edits
+func TestTokensShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		a := randomTokens(rng, rng.Intn(30), 1+rng.Intn(4))
+		b := randomTokens(rng, rng.Intn(30), 1+rng.Intn(4))
+
+		ops := tokens(a, b)
+		gotA, gotB, edits := apply(ops)
+		if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+			t.Fatalf("tokens(%v, %v) = %v does not rebuild the inputs", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); edits != want {
+			t.Fatalf("tokens(%v, %v) used %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestTokensTooManyEdits(t *testing.T) {
+	n := maxEditDistance + 10
+	a := make([]string, n)
+	b := make([]string, n)
+	for i := range a {
+		a[i] = "a"
+		b[i] = "b"
+	}
+
+	ops := tokens(a, b)
+	if !reflect.DeepEqual(ops, replaceAll(a, b)) {
+		t.Errorf("tokens() with %d edits is not a full replacement", 2*n)
+	}
+}
+
+func randomTokens(rng *rand.Rand, n, alphabet int) []string {
+	var s []string
+	for i := 0; i < n; i++ {
+		s = append(s, string(rune('a'+rng.Intn(alphabet))))
+	}
+	return s
+}
+
+// apply returns the two sides described by ops and the number of edits
+func apply(ops []Op) (a, b []string, edits int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case Equal:
+			a = append(a, op.Text)
+			b = append(b, op.Text)
+		case Delete:
+			a = append(a, op.Text)
+			edits++
+		case Insert:
+			b = append(b, op.Text)
+			edits++
+		}
+	}
+	return a, b, edits
+}
+
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
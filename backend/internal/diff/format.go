@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each hunk
+const DefaultContext = 3
+
+// Stats counts changed lines in a line diff
+type Stats struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// String summarises s, e.g. "3 lines added, 1 line removed"
+func (s Stats) String() string {
+	var parts []string
+	if s.Added > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s added", s.Added, plural(s.Added, "line")))
+	}
+	if s.Removed > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s removed", s.Removed, plural(s.Removed, "line")))
+	}
+	if len(parts) == 0 {
+		return "no lines changed"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// CountLines returns how many lines were added and removed in ops.
+func CountLines(ops []Op) Stats {
+	var s Stats
+	for _, op := range ops {
+		switch op.Kind {
+		case Insert:
+			s.Added++
+		case Delete:
+			s.Removed++
+		}
+	}
+	return s
+}
+
+// Unified renders a line diff in unified format with the given number of
+// context lines. An empty string is returned when nothing changed.
+func Unified(ops []Op, fromName, toName string, context int) string {
+	var sb strings.Builder
+
+	// aLine[i] and bLine[i] are the 0-based line numbers before ops[i]
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if op.Kind != Insert {
+			aLine[i+1]++
+		}
+		if op.Kind != Delete {
+			bLine[i+1]++
+		}
+	}
+
+	i := 0
+	for i < len(ops) {
+		// Find the next change
+		for i < len(ops) && ops[i].Kind == Equal {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		start := max(i-context, 0)
+		last := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].Kind != Equal {
+				last = j
+			} else if j-last > 2*context {
+				break
+			}
+		}
+		end := min(last+context+1, len(ops))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		aCount := aLine[end] - aLine[start]
+		bCount := bLine[end] - bLine[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, op := range ops[start:end] {
+			switch op.Kind {
+			case Equal:
+				sb.WriteString(" ")
+			case Insert:
+				sb.WriteString("+")
+			case Delete:
+				sb.WriteString("-")
+			}
+			sb.WriteString(op.Text)
+			sb.WriteString("\n")
+		}
+
+		i = end
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats one side of a hunk header. Empty ranges point at the
+// line before the hunk, as GNU diff does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// HTML renders word-level ops as an escaped <pre> block with insertions
+// wrapped in <ins> and deletions in <del>.
+func HTML(ops []Op) string {
+	var sb strings.Builder
+	sb.WriteString(`<pre class="diff">`)
+	for _, op := range ops {
+		text := html.EscapeString(op.Text)
+		switch op.Kind {
+		case Insert:
+			sb.WriteString(`<ins class="diff-insert">` + text + `</ins>`)
+		case Delete:
+			sb.WriteString(`<del class="diff-delete">` + text + `</del>`)
+		default:
+			sb.WriteString(text)
+		}
+	}
+	sb.WriteString(`</pre>`)
+	return sb.String()
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"justping/backend/internal/scheduler"
+	"log"
+	"net/http"
+	"os"
+)
+
+// verifyAdmin checks the x-admin-key header against ADMIN_API_KEY.
+// Admin endpoints are disabled when ADMIN_API_KEY is not set.
+func verifyAdmin(r *http.Request) bool {
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("x-admin-key")), []byte(adminKey)) == 1
+}
+
+// HandleReconcile handles GET/POST /api/admin/reconcile
+// Compares monitors with changedetection.io watches. GET, or POST with
+// ?dryRun=true, only reports discrepancies; POST repairs them. Orphaned
+// watches are only deleted with ?deleteOrphans=true. ?claimLegacy=true
+// also deletes orphans without notification URLs, once, for watches left
+// behind before JustPing set them.
+func HandleReconcile(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !verifyAdmin(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun := r.Method == http.MethodGet
+	switch r.URL.Query().Get("dryRun") {
+	case "true", "1":
+		dryRun = true
+	}
+
+	deleteOrphans := false
+	switch r.URL.Query().Get("deleteOrphans") {
+	case "true", "1":
+		deleteOrphans = true
+	}
+
+	claimLegacy := false
+	switch r.URL.Query().Get("claimLegacy") {
+	case "true", "1":
+		claimLegacy = true
+	}
+
+	report, err := scheduler.Reconcile(dryRun, deleteOrphans, claimLegacy)
+	if err != nil {
+		log.Printf("Reconcile: %v", err)
+		http.Error(w, "Reconcile failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Reconcile (dryRun=%t, deleteOrphans=%t, claimLegacy=%t): %d issues", dryRun, deleteOrphans, claimLegacy, len(report.Issues))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Actions accepted by the per-alert and bulk alert endpoints
+const (
+	alertActionCheck     = "check"
+	alertActionUncheck   = "uncheck"
+	alertActionArchive   = "archive"
+	alertActionUnarchive = "unarchive"
+	alertActionDelete    = "delete"
+)
+
+// maxBulkAlerts caps how many alert IDs one bulk request may list, and how
+// many alerts a bulk delete removes at a time
+const maxBulkAlerts = 500
+
+// AlertByID handles /api/alerts/:id routes:
+//
+//	DELETE /api/alerts/:id            - delete the alert
+//	POST   /api/alerts/:id/check      - mark as checked
+//	POST   /api/alerts/:id/uncheck    - mark as unchecked
+//	POST   /api/alerts/:id/archive    - hide from the alert list
+//	POST   /api/alerts/:id/unarchive  - restore an archived alert
+//	GET    /api/alerts/:id/deliveries - notification delivery attempts
+func AlertByID(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" || len(parts) > 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	alertID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid alert ID format", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "deliveries" {
+		listAlertDeliveries(w, r, alertID)
+		return
+	}
+
+	var action string
+	switch {
+	case len(parts) == 1:
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		action = alertActionDelete
+	case parts[1] != alertActionDelete && isAlertAction(parts[1]):
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		action = parts[1]
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Alerts: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := applyAlertAction(ctx, bson.M{"_id": alertID, "userId": userID}, action)
+	if err != nil {
+		log.Printf("Alerts: failed to %s alert %s: %v", action, alertID.Hex(), err)
+		http.Error(w, "Failed to update alert", http.StatusInternalServerError)
+		return
+	}
+	if count == 0 {
+		http.Error(w, "Alert not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"action": action,
+		"id":     alertID.Hex(),
+	})
+}
+
+// BulkAlerts handles POST /api/alerts/bulk. The body names an action and
+// either a list of alert IDs or a filter using the same fields as the
+// ListAlerts query params, e.g. {"action": "check", "filter": {"monitorId": "..."}}.
+func BulkAlerts(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Alerts: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.BulkAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !isAlertAction(req.Action) {
+		http.Error(w, "Invalid action: use check, uncheck, archive, unarchive or delete", http.StatusBadRequest)
+		return
+	}
+
+	var filter bson.M
+	switch {
+	case len(req.IDs) > 0 && req.Filter != nil:
+		http.Error(w, "Use either ids or filter, not both", http.StatusBadRequest)
+		return
+	case len(req.IDs) > 0:
+		if len(req.IDs) > maxBulkAlerts {
+			http.Error(w, fmt.Sprintf("Too many ids: at most %d per request", maxBulkAlerts), http.StatusBadRequest)
+			return
+		}
+		ids := bson.A{}
+		for _, id := range req.IDs {
+			alertID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				http.Error(w, "Invalid alert ID format: "+id, http.StatusBadRequest)
+				return
+			}
+			ids = append(ids, alertID)
+		}
+		filter = bson.M{"_id": bson.M{"$in": ids}, "userId": userID}
+	case req.Filter != nil:
+		filter, err = parseAlertFilter(alertFilterValues(req.Filter), userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Missing required field: ids or filter", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	count, err := applyAlertAction(ctx, filter, req.Action)
+	if err != nil {
+		log.Printf("Alerts: bulk %s failed for user %s: %v", req.Action, userID, err)
+		http.Error(w, "Failed to update alerts", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Bulk alert %s: %d alerts for user %s", req.Action, count, userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"action": req.Action,
+		"count":  count,
+	})
+}
+
+func isAlertAction(action string) bool {
+	switch action {
+	case alertActionCheck, alertActionUncheck, alertActionArchive, alertActionUnarchive, alertActionDelete:
+		return true
+	}
+	return false
+}
+
+// applyAlertAction runs action against every alert matching filter and
+// returns how many alerts matched
+func applyAlertAction(ctx context.Context, filter bson.M, action string) (int64, error) {
+	alertsCollection := database.GetAlertsCollection()
+
+	var update bson.M
+	switch action {
+	case alertActionDelete:
+		// Delete in batches of IDs so the alerts' delivery records can go
+		// too without one unbounded $in
+		var deleted int64
+		findOptions := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(maxBulkAlerts)
+		for {
+			cursor, err := alertsCollection.Find(ctx, filter, findOptions)
+			if err != nil {
+				return deleted, err
+			}
+			var matched []models.Alert
+			if err := cursor.All(ctx, &matched); err != nil {
+				return deleted, err
+			}
+			if len(matched) == 0 {
+				return deleted, nil
+			}
+			ids := make(bson.A, 0, len(matched))
+			for _, alert := range matched {
+				ids = append(ids, alert.ID)
+			}
+
+			result, err := alertsCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+			if err != nil {
+				return deleted, err
+			}
+			deleted += result.DeletedCount
+			if _, err := database.GetDeliveriesCollection().DeleteMany(ctx, bson.M{"alertId": bson.M{"$in": ids}}); err != nil {
+				log.Printf("Alerts: failed to delete deliveries: %v", err)
+			}
+			if len(matched) < maxBulkAlerts || result.DeletedCount == 0 {
+				return deleted, nil
+			}
+		}
+	case alertActionCheck:
+		update = bson.M{"$set": bson.M{"checked": true}}
+	case alertActionUncheck:
+		update = bson.M{"$set": bson.M{"checked": false}}
+	case alertActionArchive:
+		update = bson.M{"$set": bson.M{"archived": true, "archivedAt": time.Now()}}
+	case alertActionUnarchive:
+		update = bson.M{"$unset": bson.M{"archived": "", "archivedAt": ""}}
+	default:
+		return 0, fmt.Errorf("unknown alert action %q", action)
+	}
+
+	result, err := alertsCollection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+// alertFilterValues turns a bulk request filter into ListAlerts query params
+func alertFilterValues(f *models.AlertFilter) url.Values {
+	values := url.Values{}
+	if f.MonitorID != "" {
+		values.Set("monitorId", f.MonitorID)
+	}
+	if f.Checked != nil {
+		values.Set("checked", strconv.FormatBool(*f.Checked))
+	}
+	if f.Archived {
+		values.Set("archived", "true")
+	}
+	if f.From != "" {
+		values.Set("from", f.From)
+	}
+	if f.To != "" {
+		values.Set("to", f.To)
+	}
+	return values
+}
+
+// listAlertDeliveries handles GET /api/alerts/:id/deliveries
+func listAlertDeliveries(w http.ResponseWriter, r *http.Request, alertID primitive.ObjectID) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Alerts: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetDeliveriesCollection().Find(ctx,
+		bson.M{"alertId": alertID, "userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		log.Printf("Alerts: database error: %v", err)
+		http.Error(w, "Failed to fetch deliveries", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.Delivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		log.Printf("Alerts: cursor error: %v", err)
+		http.Error(w, "Failed to parse deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultAlertLimit = 50
+	maxAlertLimit     = 200
+)
+
+// parseAlertFilter builds the alerts query for a user from the monitorId,
+// checked, archived, from and to query params. Archived alerts are
+// excluded unless archived=true, which selects only archived alerts.
+func parseAlertFilter(query url.Values, userID string) (bson.M, error) {
+	filter := bson.M{"userId": userID, "archived": bson.M{"$ne": true}}
+
+	if v := query.Get("archived"); v != "" {
+		archived, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.New("Invalid archived: use true or false")
+		}
+		if archived {
+			filter["archived"] = true
+		}
+	}
+
+	if v := query.Get("monitorId"); v != "" {
+		monitorID, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, errors.New("Invalid monitorId")
+		}
+		filter["monitorId"] = monitorID
+	}
+
+	if v := query.Get("checked"); v != "" {
+		checked, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.New("Invalid checked: use true or false")
+		}
+		filter["checked"] = checked
+	}
+
+	receivedAt := bson.M{}
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("Invalid from: use RFC 3339")
+		}
+		receivedAt["$gte"] = from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("Invalid to: use RFC 3339")
+		}
+		receivedAt["$lte"] = to
+	}
+	if len(receivedAt) > 0 {
+		filter["receivedAt"] = receivedAt
+	}
+
+	return filter, nil
+}
+
+// encodeAlertCursor returns an opaque cursor pointing just after an alert
+func encodeAlertCursor(receivedAt time.Time, id primitive.ObjectID) string {
+	raw := fmt.Sprintf("%d_%s", receivedAt.UnixMilli(), id.Hex())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeAlertCursor reverses encodeAlertCursor
+func decodeAlertCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, err
+	}
+
+	millis, hex, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return time.Time{}, primitive.NilObjectID, errors.New("malformed cursor")
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, err
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, err
+	}
+
+	return time.UnixMilli(ms), id, nil
+}
+
+// lookupMonitorNames maps the monitor of each alert to its website name
+// using a single $in query. Monitors that no longer exist are left out.
+func lookupMonitorNames(ctx context.Context, alerts []models.Alert) (map[primitive.ObjectID]string, error) {
+	names := map[primitive.ObjectID]string{}
+	if len(alerts) == 0 {
+		return names, nil
+	}
+
+	ids := bson.A{}
+	for _, alert := range alerts {
+		if _, ok := names[alert.MonitorID]; !ok {
+			names[alert.MonitorID] = ""
+			ids = append(ids, alert.MonitorID)
+		}
+	}
+
+	cursor, err := database.GetMonitorsCollection().Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"websiteName": 1}),
+	)
+	if err != nil {
+		return names, err
+	}
+	defer cursor.Close(ctx)
+
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		return names, err
+	}
+	for _, m := range monitors {
+		names[m.ID] = m.WebsiteName
+	}
+	return names, nil
+}
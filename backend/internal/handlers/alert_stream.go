@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"justping/backend/internal/alerting"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// streamKeepAlive is how often an idle stream sends a comment so
+	// proxies do not close it
+	streamKeepAlive = 25 * time.Second
+	// streamReplayPage is how many missed alerts are loaded at a time on
+	// resume; pages are sent until the backlog is empty
+	streamReplayPage = 100
+)
+
+// StreamAlerts handles GET /api/alerts/stream, a Server-Sent Events stream
+// of the user's alerts as they are stored. Each event's ID can be sent back
+// as the Last-Event-ID header (or lastEventId query param) to replay what
+// was missed while disconnected.
+func StreamAlerts(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Alert stream: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	// Subscribe before replaying so nothing stored in between is lost
+	events, cancel := alerting.Subscribe(userID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Live events must not be sent before the whole backlog: their IDs
+	// would move the client's Last-Event-ID past alerts not replayed yet.
+	// If the replay fails, the stream is closed and the client resumes
+	// from the last event it got.
+	replayed := map[string]bool{}
+	for after := lastEventID; after != ""; {
+		missed, err := missedAlerts(r.Context(), userID, after)
+		if err != nil {
+			log.Printf("Alert stream: failed to replay after %s for user %s: %v", after, userID, err)
+			return
+		}
+		for _, event := range missed {
+			if err := writeAlertEvent(r.Context(), w, event); err != nil {
+				return
+			}
+			replayed[event.ID] = true
+		}
+		flusher.Flush()
+
+		after = ""
+		if len(missed) == streamReplayPage {
+			after = missed[len(missed)-1].ID
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind; the client resumes from its last ID
+				return
+			}
+			if replayed[event.ID] {
+				continue
+			}
+			if err := writeAlertEvent(r.Context(), w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeAlertEvent writes one SSE event carrying the alert as returned by ListAlerts
+func writeAlertEvent(ctx context.Context, w http.ResponseWriter, event alerting.Event) error {
+	monitorNames, err := lookupMonitorNames(ctx, []models.Alert{event.Alert})
+	if err != nil {
+		log.Printf("Alert stream: monitor lookup error: %v", err)
+	}
+
+	data, err := json.Marshal(newAlertResponse(event.Alert, monitorNames[event.Alert.MonitorID]))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// missedAlerts returns events for up to streamReplayPage alerts that
+// changed after lastEventID, oldest first. Alerts created since are sent as new, alerts that only
+// collected more changes as updates.
+func missedAlerts(ctx context.Context, userID, lastEventID string) ([]alerting.Event, error) {
+	since, lastID, err := alerting.ParseEventID(lastEventID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetAlertsCollection().Find(ctx,
+		bson.M{
+			"userId":   userID,
+			"archived": bson.M{"$ne": true},
+			"$or": bson.A{
+				bson.M{"lastChangeAt": bson.M{"$gt": since}},
+				bson.M{"lastChangeAt": since, "_id": bson.M{"$gt": lastID}},
+			},
+		},
+		options.Find().
+			SetSort(bson.D{{Key: "lastChangeAt", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(streamReplayPage),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []models.Alert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+
+	events := make([]alerting.Event, 0, len(alerts))
+	for _, alert := range alerts {
+		eventType := alerting.EventAlert
+		if !alert.ReceivedAt.After(since) {
+			eventType = alerting.EventAlertUpdate
+		}
+		events = append(events, alerting.Event{ID: alerting.EventID(alert), Type: eventType, Alert: alert})
+	}
+	return events, nil
+}
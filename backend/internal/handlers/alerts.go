@@ -2,14 +2,24 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
+	"justping/backend/internal/alerting"
 	"justping/backend/internal/auth"
+	"justping/backend/internal/changedetection"
 	"justping/backend/internal/database"
+	"justping/backend/internal/diff"
 	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"justping/backend/internal/scheduler"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,7 +28,9 @@ import (
 )
 
 // HandleWebhook receives webhook POST requests from changedetection.io
-// This is a public endpoint - no authentication required
+// on /api/webhook/<token>, where token is the monitor's webhook token.
+// Requests may instead be signed with WEBHOOK_SIGNING_SECRET (see
+// auth.VerifyWebhookSignature). Anything else is rejected and counted.
 func HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	enableCORS(w)
 
@@ -40,16 +52,32 @@ func HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse to extract watch_uuid
-	var payload map[string]interface{}
-	if err := json.Unmarshal(body, &payload); err != nil {
+	// Signed deliveries are verified before the payload is looked at
+	signed := false
+	if signature := r.Header.Get(auth.WebhookSignatureHeader); signature != "" {
+		secret := os.Getenv("WEBHOOK_SIGNING_SECRET")
+		if secret == "" {
+			rejectWebhook(w, r, "signing_disabled", "")
+			return
+		}
+		timestamp := r.Header.Get(auth.WebhookTimestampHeader)
+		if err := auth.VerifyWebhookSignature(secret, signature, timestamp, body, time.Now()); err != nil {
+			rejectWebhook(w, r, err.Error(), "")
+			return
+		}
+		signed = true
+	}
+
+	// Parse to extract watch_uuid and the change details
+	notification, body, err := changedetection.ParseNotification(body)
+	if err != nil {
 		log.Printf("Webhook: failed to parse JSON: %v", err)
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
 	}
 
-	watchUUID, ok := payload["watch_uuid"].(string)
-	if !ok || watchUUID == "" {
+	watchUUID := notification.WatchUUID
+	if watchUUID == "" {
 		log.Printf("Webhook: missing watch_uuid in payload")
 		http.Error(w, "Missing watch_uuid in payload", http.StatusBadRequest)
 		return
@@ -57,6 +85,15 @@ func HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Webhook received for watch_uuid: %s", watchUUID)
 
+	// Unsigned deliveries must carry the monitor's token in the URL. Without
+	// one they are refused before the monitor is looked up
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/webhook"), "/")
+	legacy := os.Getenv("WEBHOOK_ALLOW_LEGACY") == "true"
+	if !signed && token == "" && !legacy {
+		rejectWebhook(w, r, "missing_credentials", watchUUID)
+		return
+	}
+
 	// Find the monitor by changeDetectionUuid
 	monitorsCollection := database.GetMonitorsCollection()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -66,40 +103,106 @@ func HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	err = monitorsCollection.FindOne(ctx, bson.M{"changeDetectionUuid": watchUUID}).Decode(&monitor)
 	if err != nil {
 		log.Printf("Webhook: no monitor found for watch_uuid %s: %v", watchUUID, err)
+		// Unauthenticated callers get the same answer as for a wrong
+		// token, so they cannot probe which watch UUIDs exist
+		if !signed {
+			rejectWebhook(w, r, auth.ErrWebhookToken.Error(), watchUUID)
+			return
+		}
 		http.Error(w, "Monitor not found for this watch_uuid", http.StatusNotFound)
 		return
 	}
 
+	if !signed {
+		switch {
+		case monitor.WebhookToken != "":
+			if err := auth.VerifyWebhookToken(token, monitor.WebhookToken); err != nil {
+				rejectWebhook(w, r, err.Error(), watchUUID)
+				return
+			}
+		case legacy:
+			log.Printf("Webhook: accepting unauthenticated delivery for legacy monitor %s", monitor.ID.Hex())
+		default:
+			rejectWebhook(w, r, "missing_credentials", watchUUID)
+			return
+		}
+	}
+
+	// Keep the payload as a BSON document so it can be read back as-is
+	var payload bson.D
+	if err := bson.UnmarshalExtJSON(body, false, &payload); err != nil {
+		log.Printf("Webhook: failed to convert payload: %v", err)
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	rawPayload, err := bson.Marshal(payload)
+	if err != nil {
+		log.Printf("Webhook: failed to encode payload: %v", err)
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
 	// Create alert document
-	alert := models.Alert{
-		ID:         primitive.NewObjectID(),
-		UserID:     monitor.UserID,
-		MonitorID:  monitor.ID,
-		Checked:    false,
-		ReceivedAt: time.Now(),
-		Payload:    bson.Raw(body),
+	receivedAt := time.Now()
+
+	// Retried deliveries of the same change share an idempotency key
+	key := webhookIdempotencyKey(notification, body, receivedAt)
+
+	alert := &models.Alert{
+		ID:           primitive.NewObjectID(),
+		UserID:       monitor.UserID,
+		MonitorID:    monitor.ID,
+		Checked:      false,
+		ReceivedAt:   receivedAt,
+		AlertDetails: webhookAlertDetails(ctx, monitor, notification, receivedAt),
+		Payload:      bson.Raw(rawPayload),
 	}
 
-	// Insert into alerts collection
-	alertsCollection := database.GetAlertsCollection()
-	_, err = alertsCollection.InsertOne(ctx, alert)
+	alert, outcome, err := alerting.Store(ctx, monitor, alert, key)
 	if err != nil {
 		log.Printf("Webhook: failed to insert alert: %v", err)
 		http.Error(w, "Failed to store alert", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Alert created for user %s, monitor %s", monitor.UserID, monitor.ID.Hex())
+	message := "Alert created successfully"
+	switch outcome {
+	case alerting.Duplicate:
+		log.Printf("Webhook: ignoring duplicate delivery %s", key)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"message": "Duplicate delivery ignored",
+		})
+		return
+	case alerting.Collapsed:
+		log.Printf("Alert %s for monitor %s now covers %d changes", alert.ID.Hex(), monitor.ID.Hex(), alert.Count)
+		message = "Change added to existing alert"
+	default:
+		log.Printf("Alert created for user %s, monitor %s", monitor.UserID, monitor.ID.Hex())
+		notifier.Dispatch(ctx, *alert, monitor)
+	}
+
+	// Keep a copy of the content that triggered the alert, without holding
+	// up changedetection.io
+	go storeWatchSnapshot(monitor, receivedAt)
+
+	// first_change monitors are done once they have alerted
+	scheduler.StopAfterChange(ctx, &monitor)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
 		"status":  "ok",
-		"message": "Alert created successfully",
+		"message": message,
 	})
 }
 
 // ListAlerts handles GET /api/alerts - returns alerts for the authenticated user
+// newest first, one page at a time. Query params: limit, cursor (nextCursor
+// of the previous page), monitorId, checked (true/false), archived (true
+// lists the archive instead), from and to (RFC 3339 bounds on receivedAt).
+// total and unread count every alert matching the filters, not just the page.
 func ListAlerts(w http.ResponseWriter, r *http.Request) {
 	enableCORS(w)
 
@@ -128,13 +231,61 @@ func ListAlerts(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Fetching alerts for user: %s", userID)
 
-	// Query alerts sorted by receivedAt DESC
+	query := r.URL.Query()
+
+	limit := defaultAlertLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxAlertLimit)
+	}
+
+	filter, err := parseAlertFilter(query, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Count before the cursor narrows the filter down to one page
 	alertsCollection := database.GetAlertsCollection()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	findOptions := options.Find().SetSort(bson.D{{Key: "receivedAt", Value: -1}})
-	cursor, err := alertsCollection.Find(ctx, bson.M{"userId": userID}, findOptions)
+	total, err := alertsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Printf("Alerts: database error: %v", err)
+		http.Error(w, "Failed to count alerts", http.StatusInternalServerError)
+		return
+	}
+	unreadFilter := bson.M{"$and": bson.A{filter, bson.M{"checked": false}}}
+	unread, err := alertsCollection.CountDocuments(ctx, unreadFilter)
+	if err != nil {
+		log.Printf("Alerts: database error: %v", err)
+		http.Error(w, "Failed to count alerts", http.StatusInternalServerError)
+		return
+	}
+
+	pageFilter := filter
+	if v := query.Get("cursor"); v != "" {
+		receivedAt, id, err := decodeAlertCursor(v)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		pageFilter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"receivedAt": bson.M{"$lt": receivedAt}},
+			bson.M{"receivedAt": receivedAt, "_id": bson.M{"$lt": id}},
+		}}}}
+	}
+
+	// Query one extra alert to know whether another page follows
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "receivedAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+	cursor, err := alertsCollection.Find(ctx, pageFilter, findOptions)
 	if err != nil {
 		log.Printf("Alerts: database error: %v", err)
 		http.Error(w, "Failed to fetch alerts", http.StatusInternalServerError)
@@ -149,33 +300,23 @@ func ListAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build response with parsed payload and monitor name lookup
-	monitorsCollection := database.GetMonitorsCollection()
-	var response []models.AlertResponse
-
-	for _, alert := range alerts {
-		// Parse payload from bson.Raw to map
-		var payloadMap map[string]interface{}
-		if err := bson.Unmarshal(alert.Payload, &payloadMap); err != nil {
-			payloadMap = map[string]interface{}{"raw": string(alert.Payload)}
-		}
+	var nextCursor string
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+		last := alerts[limit-1]
+		nextCursor = encodeAlertCursor(last.ReceivedAt, last.ID)
+	}
 
-		// Look up monitor name
-		var monitor models.Monitor
-		var monitorName string
-		if err := monitorsCollection.FindOne(ctx, bson.M{"_id": alert.MonitorID}).Decode(&monitor); err == nil {
-			monitorName = monitor.WebsiteName
-		}
+	// Look up the names of every monitor on the page in one query
+	monitorNames, err := lookupMonitorNames(ctx, alerts)
+	if err != nil {
+		log.Printf("Alerts: monitor lookup error: %v", err)
+	}
 
-		response = append(response, models.AlertResponse{
-			ID:          alert.ID,
-			UserID:      alert.UserID,
-			MonitorID:   alert.MonitorID,
-			MonitorName: monitorName,
-			Checked:     alert.Checked,
-			ReceivedAt:  alert.ReceivedAt,
-			Payload:     payloadMap,
-		})
+	// Build response with parsed payload and monitor name
+	var response []models.AlertResponse
+	for _, alert := range alerts {
+		response = append(response, newAlertResponse(alert, monitorNames[alert.MonitorID]))
 	}
 
 	// Return empty array if no alerts
@@ -184,7 +325,39 @@ func ListAlerts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(models.AlertListResponse{
+		Alerts:     response,
+		NextCursor: nextCursor,
+		Total:      total,
+		Unread:     unread,
+	})
+}
+
+// newAlertResponse converts a stored alert to its JSON form
+func newAlertResponse(alert models.Alert, monitorName string) models.AlertResponse {
+	// Parse payload from bson.Raw to map
+	var payloadMap map[string]interface{}
+	if err := bson.Unmarshal(alert.Payload, &payloadMap); err != nil {
+		// Older webhook alerts stored the JSON body itself
+		if err := json.Unmarshal(alert.Payload, &payloadMap); err != nil {
+			payloadMap = map[string]interface{}{"raw": string(alert.Payload)}
+		}
+	}
+
+	return models.AlertResponse{
+		ID:                    alert.ID,
+		UserID:                alert.UserID,
+		MonitorID:             alert.MonitorID,
+		MonitorName:           monitorName,
+		Checked:               alert.Checked,
+		ReceivedAt:            alert.ReceivedAt,
+		AlertDetails:          alert.AlertDetails,
+		Count:                 alert.Count,
+		LastChangeAt:          alert.LastChangeAt,
+		LastSnapshotTimestamp: alert.LastSnapshotTimestamp,
+		EscalationLevel:       alert.EscalationLevel,
+		Payload:               payloadMap,
+	}
 }
 
 // MarkAlertsAsChecked handles POST /api/alerts/mark-checked
@@ -222,7 +395,7 @@ func MarkAlertsAsChecked(w http.ResponseWriter, r *http.Request) {
 
 	result, err := alertsCollection.UpdateMany(
 		ctx,
-		bson.M{"userId": userID, "checked": false},
+		bson.M{"userId": userID, "checked": false, "archived": bson.M{"$ne": true}},
 		bson.M{"$set": bson.M{"checked": true}},
 	)
 	if err != nil {
@@ -239,3 +412,54 @@ func MarkAlertsAsChecked(w http.ResponseWriter, r *http.Request) {
 		"markedCount":  result.ModifiedCount,
 	})
 }
+
+// webhookAlertDetails maps a changedetection.io notification to the typed
+// alert fields. The snapshot timestamps refer to the copy storeWatchSnapshot
+// keeps at receivedAt and the newest one stored before it.
+func webhookAlertDetails(ctx context.Context, monitor models.Monitor, n *changedetection.Notification, receivedAt time.Time) models.AlertDetails {
+	added, removed := n.AddedLines(), n.RemovedLines()
+	details := models.AlertDetails{
+		Source:            scheduler.BackendChangeDetection,
+		WatchUUID:         n.WatchUUID,
+		WatchURL:          n.WatchURL,
+		Title:             n.WatchTitle,
+		Summary:           diff.Stats{Added: len(added), Removed: len(removed)}.String(),
+		Diff:              n.Diff,
+		Added:             added,
+		Removed:           removed,
+		TriggeredText:     n.TriggeredText,
+		PreviewURL:        n.PreviewURL,
+		DiffURL:           n.DiffURL,
+		SnapshotTimestamp: receivedAt.Unix(),
+	}
+	if details.WatchURL == "" {
+		details.WatchURL = monitor.URL
+	}
+	if details.Title == "" {
+		details.Title = monitor.WebsiteName
+	}
+
+	if previous, err := scheduler.PreviousSnapshot(ctx, monitor.ID, receivedAt.Unix()); err == nil {
+		details.PreviousSnapshotTimestamp = previous.Timestamp
+	}
+
+	return details
+}
+
+// webhookRetryWindow is how long a payload without a snapshot timestamp is
+// treated as a retry of the same delivery. A page flipping back and forth
+// sends the same payload again later, which must not count as a retry.
+const webhookRetryWindow = time.Minute
+
+// webhookIdempotencyKey identifies a change as "<watch uuid>:<snapshot ts>"
+// when the notification names its snapshot. Otherwise a hash of the payload
+// and the minute it arrived stand in for the timestamp, so only retries
+// sent soon after the delivery are ignored.
+func webhookIdempotencyKey(n *changedetection.Notification, body []byte, receivedAt time.Time) string {
+	if ts, ok := n.SnapshotTimestamp(); ok {
+		return fmt.Sprintf("%s:%d", n.WatchUUID, ts)
+	}
+	sum := sha256.Sum256(body)
+	window := receivedAt.Truncate(webhookRetryWindow).Unix()
+	return fmt.Sprintf("%s:%s@%d", n.WatchUUID, hex.EncodeToString(sum[:8]), window)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"justping/backend/internal/changedetection"
+	"testing"
+	"time"
+)
+
+func TestWebhookIdempotencyKey(t *testing.T) {
+	body := []byte(`{"watch_uuid":"abc","diff":"(added) B"}`)
+	other := []byte(`{"watch_uuid":"abc","diff":"(added) C"}`)
+	at := time.Date(2026, time.March, 4, 10, 15, 20, 0, time.UTC)
+	withTimestamp := &changedetection.Notification{WatchUUID: "abc", DiffURL: "https://cd.example.com/diff/abc?from_version=1700000000&to_version=1700000600"}
+	withoutTimestamp := &changedetection.Notification{WatchUUID: "abc"}
+
+	key := webhookIdempotencyKey(withoutTimestamp, body, at)
+	tests := []struct {
+		name string
+		n    *changedetection.Notification
+		body []byte
+		at   time.Time
+		same bool // whether the key equals key
+	}{
+		{"retry in the same minute", withoutTimestamp, body, at.Add(30 * time.Second), true},
+		{"same payload later", withoutTimestamp, body, at.Add(time.Hour), false},
+		{"different payload", withoutTimestamp, other, at, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookIdempotencyKey(tt.n, tt.body, tt.at); (got == key) != tt.same {
+				t.Errorf("key %q vs %q: same = %v, want %v", got, key, got == key, tt.same)
+			}
+		})
+	}
+
+	// The snapshot timestamp identifies the change whenever it is known
+	first := webhookIdempotencyKey(withTimestamp, body, at)
+	if first != "abc:1700000600" {
+		t.Errorf("key = %q, want abc:1700000600", first)
+	}
+	if again := webhookIdempotencyKey(withTimestamp, other, at.Add(time.Hour)); again != first {
+		t.Errorf("redelivery key = %q, want %q", again, first)
+	}
+}
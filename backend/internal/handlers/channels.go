@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Channels handles GET /api/channels (list) and POST /api/channels (create)
+func Channels(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Channels: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		createChannel(w, r, userID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetChannelsCollection().Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		log.Printf("Channels: database error: %v", err)
+		http.Error(w, "Failed to fetch channels", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	channels := []models.Channel{}
+	if err := cursor.All(ctx, &channels); err != nil {
+		log.Printf("Channels: cursor error: %v", err)
+		http.Error(w, "Failed to parse channels", http.StatusInternalServerError)
+		return
+	}
+	for i := range channels {
+		channels[i].WebhookURL = maskWebhookURL(channels[i].WebhookURL)
+		channels[i].Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channels)
+}
+
+func createChannel(w http.ResponseWriter, r *http.Request, userID string) {
+	var req models.ChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Type == "" || req.WebhookURL == "" {
+		http.Error(w, "Missing required fields: type, webhookUrl", http.StatusBadRequest)
+		return
+	}
+	if err := notifier.ValidateChannel(req.Type, req.WebhookURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	channel := models.Channel{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		Type:       req.Type,
+		Name:       req.Name,
+		WebhookURL: req.WebhookURL,
+		Enabled:    true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if channel.Name == "" {
+		channel.Name = strings.ToUpper(req.Type[:1]) + req.Type[1:]
+	}
+	if channel.Type == notifier.ChannelWebhook {
+		// Returned once below; receivers verify signatures with it
+		secret, err := auth.NewWebhookToken()
+		if err != nil {
+			log.Printf("Channels: %v", err)
+			http.Error(w, "Failed to create channel", http.StatusInternalServerError)
+			return
+		}
+		channel.Secret = secret
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := database.GetChannelsCollection().InsertOne(ctx, channel); err != nil {
+		log.Printf("Channels: database error: %v", err)
+		http.Error(w, "Failed to save channel", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Created %s channel %s for user %s", channel.Type, channel.ID.Hex(), userID)
+
+	channel.WebhookURL = maskWebhookURL(channel.WebhookURL)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(channel)
+}
+
+// ChannelByID handles /api/channels/:id routes:
+//
+//	GET    /api/channels/:id            - fetch a channel
+//	PUT    /api/channels/:id            - update name, webhook URL or enabled
+//	DELETE /api/channels/:id            - remove a channel
+//	POST   /api/channels/:id/test       - send a sample alert
+//	GET    /api/channels/:id/deliveries - delivery log with every attempt
+//	POST   /api/channels/:id/secret     - rotate a webhook's signing secret
+func ChannelByID(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/channels/"), "/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && !channelActions[parts[1]]) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	channelID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid channel ID format", http.StatusBadRequest)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Channels: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	collection := database.GetChannelsCollection()
+	filter := bson.M{"_id": channelID, "userId": userID}
+
+	var channel models.Channel
+	if err := collection.FindOne(ctx, filter).Decode(&channel); err != nil {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "deliveries" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		filter := bson.M{"channelId": channelID, "userId": userID}
+		if v := r.URL.Query().Get("status"); v != "" {
+			filter["status"] = v
+		}
+		listDeliveries(w, r.URL.Query(), filter)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "secret" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if channel.Type != notifier.ChannelWebhook {
+			http.Error(w, "Only webhook channels have a signing secret", http.StatusBadRequest)
+			return
+		}
+		secret, err := auth.NewWebhookToken()
+		if err != nil {
+			log.Printf("Channels: %v", err)
+			http.Error(w, "Failed to rotate secret", http.StatusInternalServerError)
+			return
+		}
+		if _, err := collection.UpdateOne(ctx, filter, bson.M{
+			"$set": bson.M{"secret": secret, "updatedAt": time.Now()},
+		}); err != nil {
+			log.Printf("Channels: database error: %v", err)
+			http.Error(w, "Failed to rotate secret", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Rotated signing secret of channel %s for user %s", channelID.Hex(), userID)
+
+		channel.Secret = secret
+		channel.WebhookURL = maskWebhookURL(channel.WebhookURL)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(channel)
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := notifier.SendTest(ctx, channel); err != nil {
+			log.Printf("Channels: test message to %s failed: %v", channelID.Hex(), err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Test message failed: " + err.Error(),
+			})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"message": "Test message sent",
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req models.ChannelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Type != "" && req.Type != channel.Type {
+			http.Error(w, "Channel type cannot be changed", http.StatusBadRequest)
+			return
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"updatedAt": time.Now(),
+			},
+		}
+		if req.Name != "" {
+			update["$set"].(bson.M)["name"] = req.Name
+			channel.Name = req.Name
+		}
+		if req.WebhookURL != "" {
+			if err := notifier.ValidateChannel(channel.Type, req.WebhookURL); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			update["$set"].(bson.M)["webhookUrl"] = req.WebhookURL
+			channel.WebhookURL = req.WebhookURL
+		}
+		if req.Enabled != nil {
+			update["$set"].(bson.M)["enabled"] = *req.Enabled
+			channel.Enabled = *req.Enabled
+		}
+
+		if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+			log.Printf("Channels: database error: %v", err)
+			http.Error(w, "Failed to update channel", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		if _, err := collection.DeleteOne(ctx, filter); err != nil {
+			log.Printf("Channels: database error: %v", err)
+			http.Error(w, "Failed to delete channel", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"message": "Channel deleted",
+		})
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	channel.WebhookURL = maskWebhookURL(channel.WebhookURL)
+	channel.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channel)
+}
+
+// channelActions are the /api/channels/:id/<action> sub-routes
+var channelActions = map[string]bool{
+	"test":       true,
+	"deliveries": true,
+	"secret":     true,
+}
+
+// maskWebhookURL hides the secret tail of an incoming webhook URL, which
+// grants anyone who has it permission to post
+func maskWebhookURL(webhookURL string) string {
+	i := strings.LastIndex(webhookURL, "/")
+	if i < 0 || len(webhookURL)-i-1 <= 4 {
+		return webhookURL
+	}
+	return webhookURL[:i+5] + "…"
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Page sizes for delivery logs
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// Deliveries handles GET /api/deliveries, the user's delivery log newest
+// first. Filter with ?status= (e.g. "dead" for the dead-letter list),
+// ?channel= (notifier name) and ?channelId=; page size with ?limit=.
+func Deliveries(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Deliveries: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := bson.M{"userId": userID}
+	if v := query.Get("status"); v != "" {
+		filter["status"] = v
+	}
+	if v := query.Get("channel"); v != "" {
+		filter["channel"] = v
+	}
+	if v := query.Get("channelId"); v != "" {
+		channelID, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			http.Error(w, "Invalid channel ID format", http.StatusBadRequest)
+			return
+		}
+		filter["channelId"] = channelID
+	}
+
+	listDeliveries(w, query, filter)
+}
+
+// listDeliveries writes the deliveries matching filter, most recently
+// updated first, honouring ?limit=
+func listDeliveries(w http.ResponseWriter, query url.Values, filter bson.M) {
+	limit := defaultDeliveryLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxDeliveryLimit)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetDeliveriesCollection().Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		log.Printf("Deliveries: database error: %v", err)
+		http.Error(w, "Failed to fetch deliveries", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.Delivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		log.Printf("Deliveries: cursor error: %v", err)
+		http.Error(w, "Failed to parse deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// DeliveryByID handles /api/deliveries/:id routes:
+//
+//	GET  /api/deliveries/:id       - fetch a delivery with all its attempts
+//	POST /api/deliveries/:id/retry - send a failed or dead delivery again
+func DeliveryByID(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/deliveries/"), "/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "retry") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	deliveryID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid delivery ID format", http.StatusBadRequest)
+		return
+	}
+
+	retry := len(parts) == 2
+	if (retry && r.Method != http.MethodPost) || (!retry && r.Method != http.MethodGet) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Deliveries: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var delivery models.Delivery
+	if err := database.GetDeliveriesCollection().FindOne(ctx, bson.M{"_id": deliveryID, "userId": userID}).Decode(&delivery); err != nil {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+
+	if retry {
+		retried, err := notifier.Retry(ctx, deliveryID, userID)
+		if errors.Is(err, notifier.ErrNotRetryable) {
+			http.Error(w, "Only failed deliveries can be retried", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("Deliveries: failed to retry %s: %v", deliveryID.Hex(), err)
+			http.Error(w, "Failed to retry delivery", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Retrying %s delivery %s for user %s", retried.Channel, deliveryID.Hex(), userID)
+		delivery = *retried
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/diff"
+	"justping/backend/internal/models"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// getDiff handles GET /api/monitors/:id/diff?from=&to=
+// from and to are snapshot timestamps (or "latest"). to defaults to the
+// latest successful snapshot, from to the last one with different content.
+// format=unified or format=html returns just that view instead of JSON.
+func getDiff(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "unified" && format != "html" {
+		http.Error(w, "Invalid format: use json, unified or html", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := findUserMonitor(ctx, monitorID, userID); err != nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+
+	var to *models.Snapshot
+	var err error
+	if ts := query.Get("to"); ts != "" {
+		to, err = findSnapshot(ctx, monitorID, userID, ts)
+	} else {
+		to, err = findDiffSnapshot(ctx, bson.M{"monitorId": monitorID, "userId": userID})
+	}
+	if err != nil {
+		http.Error(w, "Snapshot not found for 'to'", http.StatusNotFound)
+		return
+	}
+
+	var from *models.Snapshot
+	if ts := query.Get("from"); ts != "" {
+		from, err = findSnapshot(ctx, monitorID, userID, ts)
+	} else {
+		from, err = findDiffSnapshot(ctx, bson.M{
+			"monitorId":   monitorID,
+			"userId":      userID,
+			"timestamp":   bson.M{"$lte": to.Timestamp},
+			"_id":         bson.M{"$ne": to.ID},
+			"contentHash": bson.M{"$ne": to.ContentHash},
+		})
+	}
+	if err != nil {
+		http.Error(w, "Snapshot not found for 'from'", http.StatusNotFound)
+		return
+	}
+
+	lineOps := diff.Lines(from.Content, to.Content)
+	fromName := fmt.Sprintf("snapshot %d", from.Timestamp)
+	toName := fmt.Sprintf("snapshot %d", to.Timestamp)
+
+	switch format {
+	case "unified":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(diff.Unified(lineOps, fromName, toName, diff.DefaultContext)))
+		return
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(diff.HTML(diff.Words(from.Content, to.Content))))
+		return
+	}
+
+	wordOps := diff.Words(from.Content, to.Content)
+	if wordOps == nil {
+		wordOps = []diff.Op{}
+	}
+
+	response := models.DiffResponse{
+		From:    models.SnapshotRef{Timestamp: from.Timestamp, CheckedAt: from.CheckedAt, ContentHash: from.ContentHash},
+		To:      models.SnapshotRef{Timestamp: to.Timestamp, CheckedAt: to.CheckedAt, ContentHash: to.ContentHash},
+		Stats:   diff.CountLines(lineOps),
+		Unified: diff.Unified(lineOps, fromName, toName, diff.DefaultContext),
+		Words:   wordOps,
+		HTML:    diff.HTML(wordOps),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// findDiffSnapshot returns the newest successful snapshot matching filter
+func findDiffSnapshot(ctx context.Context, filter bson.M) (*models.Snapshot, error) {
+	filter["error"] = bson.M{"$exists": false}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}})
+
+	var snapshot models.Snapshot
+	if err := database.GetSnapshotsCollection().FindOne(ctx, filter, findOptions).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
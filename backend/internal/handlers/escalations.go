@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EscalationPolicies handles GET /api/escalation-policies (list) and
+// POST /api/escalation-policies (create)
+func EscalationPolicies(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Escalations: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if r.Method == http.MethodPost {
+		createEscalationPolicy(ctx, w, r, userID)
+		return
+	}
+
+	cursor, err := database.GetEscalationPoliciesCollection().Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		log.Printf("Escalations: database error: %v", err)
+		http.Error(w, "Failed to fetch escalation policies", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	policies := []models.EscalationPolicy{}
+	if err := cursor.All(ctx, &policies); err != nil {
+		log.Printf("Escalations: cursor error: %v", err)
+		http.Error(w, "Failed to parse escalation policies", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+}
+
+func createEscalationPolicy(ctx context.Context, w http.ResponseWriter, r *http.Request, userID string) {
+	var req models.EscalationPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" || len(req.Steps) == 0 {
+		http.Error(w, "Missing required fields: name, steps", http.StatusBadRequest)
+		return
+	}
+	if err := notifier.ValidateEscalationSteps(ctx, userID, req.Steps); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	policy := models.EscalationPolicy{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      req.Name,
+		Steps:     req.Steps,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := database.GetEscalationPoliciesCollection().InsertOne(ctx, policy); err != nil {
+		log.Printf("Escalations: database error: %v", err)
+		http.Error(w, "Failed to save escalation policy", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Created escalation policy %s for user %s", policy.ID.Hex(), userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// EscalationPolicyByID handles /api/escalation-policies/:id routes:
+//
+//	GET    /api/escalation-policies/:id - fetch a policy
+//	PUT    /api/escalation-policies/:id - update its name or steps
+//	DELETE /api/escalation-policies/:id - remove it from the user's monitors and delete it
+func EscalationPolicyByID(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/escalation-policies/"), "/")
+	if idStr == "" || strings.Contains(idStr, "/") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	policyID, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Invalid escalation policy ID format", http.StatusBadRequest)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Escalations: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetEscalationPoliciesCollection()
+	filter := bson.M{"_id": policyID, "userId": userID}
+
+	var policy models.EscalationPolicy
+	if err := collection.FindOne(ctx, filter).Decode(&policy); err != nil {
+		http.Error(w, "Escalation policy not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req models.EscalationPolicyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"updatedAt": time.Now(),
+			},
+		}
+		if req.Name != "" {
+			update["$set"].(bson.M)["name"] = req.Name
+			policy.Name = req.Name
+		}
+		if req.Steps != nil {
+			if err := notifier.ValidateEscalationSteps(ctx, userID, req.Steps); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			update["$set"].(bson.M)["steps"] = req.Steps
+			policy.Steps = req.Steps
+		}
+
+		if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+			log.Printf("Escalations: database error: %v", err)
+			http.Error(w, "Failed to update escalation policy", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		// Detach the policy first so no monitor points at a missing one
+		if _, err := database.GetMonitorsCollection().UpdateMany(ctx,
+			bson.M{"userId": userID, "escalationPolicyId": policyID},
+			bson.M{"$unset": bson.M{"escalationPolicyId": ""}},
+		); err != nil {
+			log.Printf("Escalations: database error: %v", err)
+			http.Error(w, "Failed to delete escalation policy", http.StatusInternalServerError)
+			return
+		}
+		if _, err := collection.DeleteOne(ctx, filter); err != nil {
+			log.Printf("Escalations: database error: %v", err)
+			http.Error(w, "Failed to delete escalation policy", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "ok",
+			"message": "Escalation policy deleted",
+		})
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
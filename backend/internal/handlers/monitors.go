@@ -4,9 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"justping/backend/internal/auth"
-	"justping/backend/internal/changedetection"
 	"justping/backend/internal/database"
 	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"justping/backend/internal/scheduler"
 	"log"
 	"net/http"
 	"os"
@@ -145,39 +146,29 @@ func CreateMonitor(w http.ResponseWriter, r *http.Request) {
 		req.Frequency = models.Frequency{Value: 5, Unit: "minutes"}
 	}
 
-	// Create watch on changedetection.io
-	changeDetectionBaseURL := os.Getenv("CHANGEDETECTION_BASE_URL")
-	if changeDetectionBaseURL == "" {
-		changeDetectionBaseURL = "http://localhost:5000"
-	}
-	changeDetectionAPIKey := os.Getenv("CHANGEDETECTION_API_KEY")
-	
-	cdClient := changedetection.NewClient(changeDetectionBaseURL, changeDetectionAPIKey)
-	
-	// Prepare watch request
-	watchReq := changedetection.CreateWatchRequest{
-		URL:              req.URL,
-		Title:            req.WebsiteName,
-		TimeBetweenCheck: changedetection.MapFrequencyToTimeBetweenCheck(req.Frequency),
-		NotificationMuted: !req.AlertsEnabled,
-	}
-	
-	// Create watch on changedetection.io
-	watchUUID, err := cdClient.CreateWatch(watchReq)
+	// Token that authenticates webhooks for this monitor
+	webhookToken, err := auth.NewWebhookToken()
 	if err != nil {
-		log.Printf("ChangeDetection.io error: %v", err)
-		http.Error(w, "Failed to create monitor on change detection service", http.StatusInternalServerError)
+		log.Printf("Webhook token error: %v", err)
+		http.Error(w, "Failed to create monitor", http.StatusInternalServerError)
 		return
 	}
-	
-	log.Printf("Created watch on changedetection.io for URL: %s, UUID: %s", req.URL, watchUUID)
 
 	// Create monitor document
 	now := time.Now()
 	
+	var selector string
+	if req.Selector != nil {
+		selector = *req.Selector
+	}
+
 	// Prepare duration pointer
 	var duration *models.Duration
 	if req.Duration.Type != "" {
+		if err := scheduler.ValidateDuration(&req.Duration); err != nil {
+			http.Error(w, "Invalid duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 		duration = &req.Duration
 	}
 	
@@ -187,20 +178,55 @@ func CreateMonitor(w http.ResponseWriter, r *http.Request) {
 		WebsiteName:         req.WebsiteName,
 		TargetType:          req.TargetType,
 		URL:                 req.URL,
-		Selector:            req.Selector,
+		Selector:            selector,
 		Status:              "active",
 		LastChecked:         now,
 		Frequency:           req.Frequency,
 		HasChanged:          false,
 		CreatedAt:           now,
 		UpdatedAt:           now,
-		ChangeDetectionUUID: watchUUID,
 		Duration:            duration,
 		AlertsEnabled:       req.AlertsEnabled,
 		NotificationMethod:  req.NotificationMethod,
 		DetectionMode:       req.DetectionMode,
+		WebhookToken:        webhookToken,
+	}
+	if req.AlertCooldown != nil {
+		monitor.AlertCooldown = max(*req.AlertCooldown, 0)
+	}
+	if req.DigestFrequency != nil {
+		if *req.DigestFrequency != "" && !notifier.ValidDigestFrequency(*req.DigestFrequency) {
+			http.Error(w, "Invalid digestFrequency: use immediate, daily or weekly", http.StatusBadRequest)
+			return
+		}
+		monitor.DigestFrequency = *req.DigestFrequency
+	}
+	if req.Schedule != nil && req.Schedule.Mode != "" {
+		if err := notifier.ValidateSchedule(req.Schedule, true); err != nil {
+			http.Error(w, "Invalid schedule: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		monitor.Schedule = req.Schedule
+	}
+	if req.EscalationPolicyID != nil && *req.EscalationPolicyID != "" {
+		policy, err := findUserEscalationPolicy(ctx, *req.EscalationPolicyID, userID)
+		if err != nil {
+			http.Error(w, "Escalation policy not found", http.StatusBadRequest)
+			return
+		}
+		monitor.EscalationPolicyID = &policy.ID
+	}
+
+	// Hand the monitor to the configured check backend
+	backend := scheduler.GetBackend()
+	if err := backend.Register(&monitor); err != nil {
+		log.Printf("Check backend (%s) error: %v", backend.Name(), err)
+		http.Error(w, "Failed to create monitor on change detection service", http.StatusInternalServerError)
+		return
 	}
 
+	log.Printf("Registered monitor for URL %s with %s backend", req.URL, backend.Name())
+
 	// Insert into MongoDB
 	// collection is already defined
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
@@ -209,6 +235,11 @@ func CreateMonitor(w http.ResponseWriter, r *http.Request) {
 	result, err := collection.InsertOne(ctx, monitor)
 	if err != nil {
 		log.Printf("Database error: %v", err)
+		// Remove the watch again so it does not keep checking for a
+		// monitor that was never stored
+		if rollbackErr := backend.Unregister(&monitor); rollbackErr != nil {
+			log.Printf("Check backend (%s) rollback failed for monitor %s: %v", backend.Name(), monitor.ID.Hex(), rollbackErr)
+		}
 		http.Error(w, "Failed to create monitor", http.StatusInternalServerError)
 		return
 	}
@@ -229,14 +260,15 @@ func MonitorByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract ID from URL path
+	// Extract ID and optional sub-resource from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/api/monitors/")
-	if path == "" || path == "create" {
+	parts := strings.SplitN(path, "/", 2)
+	if parts[0] == "" || parts[0] == "create" {
 		http.Error(w, "Invalid monitor ID", http.StatusBadRequest)
 		return
 	}
 
-	monitorID, err := primitive.ObjectIDFromHex(path)
+	monitorID, err := primitive.ObjectIDFromHex(parts[0])
 	if err != nil {
 		http.Error(w, "Invalid monitor ID format", http.StatusBadRequest)
 		return
@@ -254,6 +286,11 @@ func MonitorByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 2 && strings.Trim(parts[1], "/") != "" {
+		monitorSubresource(w, r, monitorID, userID, strings.Split(strings.Trim(parts[1], "/"), "/"))
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		getMonitorByID(w, r, monitorID, userID)
@@ -266,6 +303,48 @@ func MonitorByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// monitorSubresource routes /api/monitors/:id/<sub>... requests
+func monitorSubresource(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string, sub []string) {
+	switch {
+	case sub[0] == "snapshots" && len(sub) == 1:
+		listSnapshots(w, r, monitorID, userID)
+	case sub[0] == "snapshots" && len(sub) == 2:
+		getSnapshot(w, r, monitorID, userID, sub[1])
+	case sub[0] == "diff" && len(sub) == 1:
+		getDiff(w, r, monitorID, userID)
+	case sub[0] == "pause" && len(sub) == 1:
+		pauseMonitor(w, r, monitorID, userID, true)
+	case sub[0] == "resume" && len(sub) == 1:
+		pauseMonitor(w, r, monitorID, userID, false)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// findUserMonitor loads a monitor owned by userID
+func findUserMonitor(ctx context.Context, monitorID primitive.ObjectID, userID string) (*models.Monitor, error) {
+	var monitor models.Monitor
+	err := database.GetMonitorsCollection().FindOne(ctx, bson.M{"_id": monitorID, "userId": userID}).Decode(&monitor)
+	if err != nil {
+		return nil, err
+	}
+	return &monitor, nil
+}
+
+// findUserEscalationPolicy loads an escalation policy owned by userID
+func findUserEscalationPolicy(ctx context.Context, id, userID string) (*models.EscalationPolicy, error) {
+	policyID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var policy models.EscalationPolicy
+	err = database.GetEscalationPoliciesCollection().FindOne(ctx, bson.M{"_id": policyID, "userId": userID}).Decode(&policy)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
 func getMonitorByID(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string) {
 	collection := database.GetMonitorsCollection()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -289,40 +368,140 @@ func updateMonitor(w http.ResponseWriter, r *http.Request, monitorID primitive.O
 		return
 	}
 
-	// Build update document
+	collection := database.GetMonitorsCollection()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	existing, err := findUserMonitor(ctx, monitorID, userID)
+	if err != nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+
+	// Build update document, tracking the monitor as it will look afterwards
 	update := bson.M{
 		"$set": bson.M{
 			"updatedAt": time.Now(),
 		},
 	}
+	updated := *existing
 
 	if updateReq.WebsiteName != "" {
 		update["$set"].(bson.M)["websiteName"] = updateReq.WebsiteName
+		updated.WebsiteName = updateReq.WebsiteName
 	}
 	if updateReq.TargetType != "" {
 		update["$set"].(bson.M)["targetType"] = updateReq.TargetType
+		updated.TargetType = updateReq.TargetType
 	}
 	if updateReq.URL != "" {
 		update["$set"].(bson.M)["url"] = updateReq.URL
+		updated.URL = updateReq.URL
 	}
-	if updateReq.Selector != "" {
-		update["$set"].(bson.M)["selector"] = updateReq.Selector
+	if updateReq.Selector != nil {
+		update["$set"].(bson.M)["selector"] = *updateReq.Selector
+		updated.Selector = *updateReq.Selector
 	}
 	if updateReq.Frequency.Value > 0 {
 		update["$set"].(bson.M)["frequency"] = updateReq.Frequency
+		updated.Frequency = updateReq.Frequency
+	}
+	if updateReq.Duration.Type != "" {
+		if err := scheduler.ValidateDuration(&updateReq.Duration); err != nil {
+			http.Error(w, "Invalid duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		update["$set"].(bson.M)["duration"] = updateReq.Duration
+		updated.Duration = &updateReq.Duration
+	}
+	if updateReq.AlertCooldown != nil {
+		update["$set"].(bson.M)["alertCooldown"] = max(*updateReq.AlertCooldown, 0)
+		updated.AlertCooldown = max(*updateReq.AlertCooldown, 0)
+	}
+	if updateReq.DigestFrequency != nil {
+		if *updateReq.DigestFrequency != "" && !notifier.ValidDigestFrequency(*updateReq.DigestFrequency) {
+			http.Error(w, "Invalid digestFrequency: use immediate, daily or weekly", http.StatusBadRequest)
+			return
+		}
+		update["$set"].(bson.M)["digestFrequency"] = *updateReq.DigestFrequency
+		updated.DigestFrequency = *updateReq.DigestFrequency
+	}
+	if updateReq.Schedule != nil {
+		if err := notifier.ValidateSchedule(updateReq.Schedule, true); err != nil {
+			http.Error(w, "Invalid schedule: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		// An empty mode goes back to following the user's schedule
+		updated.Schedule = nil
+		if updateReq.Schedule.Mode != "" {
+			updated.Schedule = updateReq.Schedule
+		}
+		update["$set"].(bson.M)["schedule"] = updated.Schedule
+	}
+	if updateReq.EscalationPolicyID != nil {
+		updated.EscalationPolicyID = nil
+		if *updateReq.EscalationPolicyID != "" {
+			policy, err := findUserEscalationPolicy(ctx, *updateReq.EscalationPolicyID, userID)
+			if err != nil {
+				http.Error(w, "Escalation policy not found", http.StatusBadRequest)
+				return
+			}
+			updated.EscalationPolicyID = &policy.ID
+		}
+		update["$set"].(bson.M)["escalationPolicyId"] = updated.EscalationPolicyID
+	}
+
+	// A different page or element starts a new baseline instead of alerting
+	if updated.URL != existing.URL || updated.Selector != existing.Selector {
+		update["$unset"] = bson.M{"contentHash": ""}
+	}
+
+	// Monitors created before webhook tokens get one now, so the backend
+	// can be pointed at an authenticated webhook URL
+	if existing.WebhookToken == "" {
+		webhookToken, err := auth.NewWebhookToken()
+		if err != nil {
+			log.Printf("Webhook token error: %v", err)
+			http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
+			return
+		}
+		update["$set"].(bson.M)["webhookToken"] = webhookToken
+		updated.WebhookToken = webhookToken
+	}
+
+	// Apply the change to the check backend first; if that fails the local
+	// document is left untouched
+	backend := scheduler.BackendFor(existing)
+	remoteChanged := updated.WebsiteName != existing.WebsiteName ||
+		updated.URL != existing.URL ||
+		updated.Selector != existing.Selector ||
+		updated.Frequency != existing.Frequency ||
+		updated.WebhookToken != existing.WebhookToken
+	if remoteChanged {
+		if err := backend.Update(&updated); err != nil {
+			log.Printf("Check backend (%s) update error for monitor %s: %v", backend.Name(), monitorID.Hex(), err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Failed to update monitor on change detection service: " + err.Error(),
+			})
+			return
+		}
 	}
 
-	collection := database.GetMonitorsCollection()
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	result, err := collection.UpdateOne(ctx, bson.M{"_id": monitorID, "userId": userID}, update)
-	if err != nil {
-		http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
-		return
-	}
-
-	if result.MatchedCount == 0 {
+	if err != nil || result.MatchedCount == 0 {
+		// Put the backend back the way it was so both sides still agree
+		if remoteChanged {
+			if rollbackErr := backend.Update(existing); rollbackErr != nil {
+				log.Printf("Check backend (%s) rollback failed for monitor %s: %v", backend.Name(), monitorID.Hex(), rollbackErr)
+			}
+		}
+		if err != nil {
+			log.Printf("Database error: %v", err)
+			http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
+			return
+		}
 		http.Error(w, "Monitor not found", http.StatusNotFound)
 		return
 	}
@@ -335,13 +514,43 @@ func updateMonitor(w http.ResponseWriter, r *http.Request, monitorID primitive.O
 	json.NewEncoder(w).Encode(monitor)
 }
 
+// deleteMonitor removes the monitor, its backend watch, snapshots and alerts.
+// Alerts are deleted by default; ?alerts=archive keeps them archived instead.
+// Failures after the monitor itself is gone are reported as warnings.
 func deleteMonitor(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string) {
+	alertsMode := r.URL.Query().Get("alerts")
+	if alertsMode == "" {
+		alertsMode = "delete"
+	}
+	if alertsMode != "delete" && alertsMode != "archive" {
+		http.Error(w, "Invalid alerts mode: use delete or archive", http.StatusBadRequest)
+		return
+	}
+
 	collection := database.GetMonitorsCollection()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	monitor, err := findUserMonitor(ctx, monitorID, userID)
+	if err != nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+
+	warnings := []string{}
+
+	// Stop the backend first. If that fails the monitor is still deleted;
+	// the reconciler removes the leftover watch later when
+	// RECONCILE_DELETE_ORPHANS is not turned off.
+	backend := scheduler.BackendFor(monitor)
+	if err := backend.Unregister(monitor); err != nil {
+		log.Printf("Check backend (%s) delete error for monitor %s: %v", backend.Name(), monitorID.Hex(), err)
+		warnings = append(warnings, "Failed to delete watch on change detection service: "+err.Error())
+	}
+
 	result, err := collection.DeleteOne(ctx, bson.M{"_id": monitorID, "userId": userID})
 	if err != nil {
+		log.Printf("Database error: %v", err)
 		http.Error(w, "Failed to delete monitor", http.StatusInternalServerError)
 		return
 	}
@@ -351,6 +560,54 @@ func deleteMonitor(w http.ResponseWriter, r *http.Request, monitorID primitive.O
 		return
 	}
 
+	response := map[string]interface{}{
+		"message": "Monitor deleted successfully",
+	}
+
+	// Cascade to alerts
+	alertsCollection := database.GetAlertsCollection()
+	alertsFilter := bson.M{"monitorId": monitorID, "userId": userID}
+	if alertsMode == "archive" {
+		now := time.Now()
+		res, err := alertsCollection.UpdateMany(ctx, alertsFilter, bson.M{"$set": bson.M{"archived": true, "archivedAt": now}})
+		if err != nil {
+			log.Printf("Database error archiving alerts for monitor %s: %v", monitorID.Hex(), err)
+			warnings = append(warnings, "Failed to archive alerts")
+		} else {
+			response["alertsArchived"] = res.ModifiedCount
+		}
+	} else {
+		res, err := alertsCollection.DeleteMany(ctx, alertsFilter)
+		if err != nil {
+			log.Printf("Database error deleting alerts for monitor %s: %v", monitorID.Hex(), err)
+			warnings = append(warnings, "Failed to delete alerts")
+		} else {
+			response["alertsDeleted"] = res.DeletedCount
+		}
+
+		// Delivery records go with the alerts they were for
+		if _, err := database.GetDeliveriesCollection().DeleteMany(ctx, alertsFilter); err != nil {
+			log.Printf("Database error deleting deliveries for monitor %s: %v", monitorID.Hex(), err)
+			warnings = append(warnings, "Failed to delete notification deliveries")
+		}
+	}
+
+	// Snapshots are meaningless without the monitor
+	res, err := database.GetSnapshotsCollection().DeleteMany(ctx, bson.M{"monitorId": monitorID, "userId": userID})
+	if err != nil {
+		log.Printf("Database error deleting snapshots for monitor %s: %v", monitorID.Hex(), err)
+		warnings = append(warnings, "Failed to delete snapshots")
+	} else {
+		response["snapshotsDeleted"] = res.DeletedCount
+	}
+
+	if len(warnings) > 0 {
+		response["message"] = "Monitor deleted with warnings"
+		response["warnings"] = warnings
+	}
+
+	log.Printf("Deleted monitor %s for user %s", monitorID.Hex(), userID)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Monitor deleted successfully"})
+	json.NewEncoder(w).Encode(response)
 }
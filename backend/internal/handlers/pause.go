@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/models"
+	"justping/backend/internal/scheduler"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxBulkMonitors caps how many monitors one bulk request may touch
+const maxBulkMonitors = 100
+
+// errDurationEnded is returned when resuming a monitor past its end date
+const errDurationEnded = "Monitor's end date has passed: set a new end date before resuming"
+
+// pauseMonitor handles POST /api/monitors/:id/pause and /resume
+func pauseMonitor(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string, paused bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	monitor, err := findUserMonitor(ctx, monitorID, userID)
+	if err != nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+
+	// The scheduler would pause it again straight away
+	if !paused && scheduler.DurationEnded(monitor.Duration, time.Now()) {
+		http.Error(w, errDurationEnded, http.StatusConflict)
+		return
+	}
+
+	if err := scheduler.SetPaused(ctx, monitor, paused, ""); err != nil {
+		log.Printf("Pause: failed to update monitor %s: %v", monitorID.Hex(), err)
+		status := http.StatusInternalServerError
+		var backendErr scheduler.BackendError
+		if errors.As(err, &backendErr) {
+			status = http.StatusBadGateway
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to update monitor: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(monitor)
+}
+
+// PauseMonitors handles POST /api/monitors/pause with {"ids": [...]}
+func PauseMonitors(w http.ResponseWriter, r *http.Request) {
+	bulkSetPaused(w, r, true)
+}
+
+// ResumeMonitors handles POST /api/monitors/resume with {"ids": [...]}
+func ResumeMonitors(w http.ResponseWriter, r *http.Request) {
+	bulkSetPaused(w, r, false)
+}
+
+func bulkSetPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Pause: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.BulkMonitorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "Missing required field: ids", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > maxBulkMonitors {
+		http.Error(w, fmt.Sprintf("Too many ids: at most %d per request", maxBulkMonitors), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	updated := []string{}
+	failed := []map[string]string{}
+	for _, id := range req.IDs {
+		monitorID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			failed = append(failed, map[string]string{"id": id, "error": "Invalid monitor ID format"})
+			continue
+		}
+
+		monitor, err := findUserMonitor(ctx, monitorID, userID)
+		if err != nil {
+			failed = append(failed, map[string]string{"id": id, "error": "Monitor not found"})
+			continue
+		}
+		if !paused && scheduler.DurationEnded(monitor.Duration, time.Now()) {
+			failed = append(failed, map[string]string{"id": id, "error": errDurationEnded})
+			continue
+		}
+
+		if err := scheduler.SetPaused(ctx, monitor, paused, ""); err != nil {
+			log.Printf("Pause: failed to update monitor %s: %v", id, err)
+			failed = append(failed, map[string]string{"id": id, "error": err.Error()})
+			continue
+		}
+		updated = append(updated, id)
+	}
+
+	log.Printf("Bulk %s: %d updated, %d failed for user %s", pauseAction(paused), len(updated), len(failed), userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"updated": updated,
+		"failed":  failed,
+	})
+}
+
+func pauseAction(paused bool) string {
+	if paused {
+		return "paused"
+	}
+	return "resumed"
+}
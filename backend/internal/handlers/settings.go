@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationSettings handles GET and PUT /api/settings/notifications,
+// the user's timezone and digest schedule
+func NotificationSettings(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Settings: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	settings, err := notifier.UserSettings(ctx, userID)
+	if err != nil {
+		log.Printf("Settings: database error: %v", err)
+		http.Error(w, "Failed to fetch settings", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var req models.NotificationSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Timezone != nil {
+			if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+				http.Error(w, "Invalid timezone: use an IANA name such as Europe/Berlin", http.StatusBadRequest)
+				return
+			}
+			settings.Timezone = *req.Timezone
+		}
+		if req.DigestFrequency != nil {
+			if !notifier.ValidDigestFrequency(*req.DigestFrequency) {
+				http.Error(w, "Invalid digestFrequency: use immediate, daily or weekly", http.StatusBadRequest)
+				return
+			}
+			settings.DigestFrequency = *req.DigestFrequency
+		}
+		if req.DigestTime != nil {
+			if _, err := time.Parse("15:04", *req.DigestTime); err != nil {
+				http.Error(w, "Invalid digestTime: use HH:MM", http.StatusBadRequest)
+				return
+			}
+			settings.DigestTime = *req.DigestTime
+		}
+		if req.DigestWeekday != nil {
+			if *req.DigestWeekday < 0 || *req.DigestWeekday > 6 {
+				http.Error(w, "Invalid digestWeekday: use 0 (Sunday) to 6 (Saturday)", http.StatusBadRequest)
+				return
+			}
+			settings.DigestWeekday = *req.DigestWeekday
+		}
+		if req.Schedule != nil {
+			if err := notifier.ValidateSchedule(req.Schedule, false); err != nil {
+				http.Error(w, "Invalid schedule: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			settings.Schedule = req.Schedule
+		}
+
+		settings.UpdatedAt = time.Now()
+		if _, err := database.GetSettingsCollection().ReplaceOne(ctx,
+			bson.M{"_id": userID}, settings, options.Replace().SetUpsert(true),
+		); err != nil {
+			log.Printf("Settings: database error: %v", err)
+			http.Error(w, "Failed to save settings", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Updated notification settings for user %s", userID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
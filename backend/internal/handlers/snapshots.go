@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"justping/backend/internal/changedetection"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"justping/backend/internal/scheduler"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultSnapshotLimit = 50
+	maxSnapshotLimit     = 500
+)
+
+// listSnapshots handles GET /api/monitors/:id/snapshots
+// Returns snapshot metadata (without content), newest first.
+// Optional query params: limit, before (unix seconds)
+func listSnapshots(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultSnapshotLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxSnapshotLimit)
+	}
+
+	filter := bson.M{"monitorId": monitorID, "userId": userID}
+	if v := r.URL.Query().Get("before"); v != "" {
+		before, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid before timestamp", http.StatusBadRequest)
+			return
+		}
+		filter["timestamp"] = bson.M{"$lt": before}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := findUserMonitor(ctx, monitorID, userID); err != nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"content": 0})
+	cursor, err := database.GetSnapshotsCollection().Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("Snapshots: database error: %v", err)
+		http.Error(w, "Failed to fetch snapshots", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var snapshots []models.Snapshot
+	if err = cursor.All(ctx, &snapshots); err != nil {
+		log.Printf("Snapshots: cursor error: %v", err)
+		http.Error(w, "Failed to parse snapshots", http.StatusInternalServerError)
+		return
+	}
+
+	// Return empty array if no snapshots
+	if snapshots == nil {
+		snapshots = []models.Snapshot{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+// getSnapshot handles GET /api/monitors/:id/snapshots/:ts
+// ts is a unix timestamp from the listing, or "latest"
+func getSnapshot(w http.ResponseWriter, r *http.Request, monitorID primitive.ObjectID, userID string, ts string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshot, err := findSnapshot(ctx, monitorID, userID, ts)
+	if err != nil {
+		http.Error(w, "Snapshot not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// findSnapshot loads a user's snapshot by unix timestamp or "latest"
+func findSnapshot(ctx context.Context, monitorID primitive.ObjectID, userID string, ts string) (*models.Snapshot, error) {
+	filter := bson.M{"monitorId": monitorID, "userId": userID}
+	if ts != "latest" {
+		timestamp, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		filter["timestamp"] = timestamp
+	}
+
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}})
+
+	var snapshot models.Snapshot
+	if err := database.GetSnapshotsCollection().FindOne(ctx, filter, findOptions).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// storeWatchSnapshotTimeout bounds fetching and storing a changedetection.io
+// snapshot
+const storeWatchSnapshotTimeout = 30 * time.Second
+
+// storeWatchSnapshot copies the latest changedetection.io snapshot for a
+// monitor into the snapshots collection. It runs outside the webhook
+// request, so failures are logged, not returned: the alert has already been
+// stored. changedetection.io does not tell us the status code or fetch time
+// of its check, so those stay empty.
+func storeWatchSnapshot(monitor models.Monitor, checkedAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeWatchSnapshotTimeout)
+	defer cancel()
+
+	content, err := changedetection.NewClientFromEnv().GetSnapshot(ctx, monitor.ChangeDetectionUUID, "latest")
+
+	snapshot := models.Snapshot{
+		ID:        primitive.NewObjectID(),
+		UserID:    monitor.UserID,
+		MonitorID: monitor.ID,
+		Timestamp: checkedAt.Unix(),
+		CheckedAt: checkedAt,
+		Source:    "changedetection",
+	}
+	if err != nil {
+		log.Printf("Snapshots: failed to fetch snapshot for watch %s: %v", monitor.ChangeDetectionUUID, err)
+		snapshot.Error = err.Error()
+	} else {
+		sum := sha256.Sum256([]byte(content))
+		snapshot.Content = content
+		snapshot.ContentHash = hex.EncodeToString(sum[:])
+	}
+
+	if _, err := database.GetSnapshotsCollection().InsertOne(ctx, snapshot); err != nil {
+		log.Printf("Snapshots: failed to store snapshot for monitor %s: %v", monitor.ID.Hex(), err)
+		return
+	}
+	if err := scheduler.PruneSnapshots(ctx, monitor.ID); err != nil {
+		log.Printf("Snapshots: failed to prune snapshots of monitor %s: %v", monitor.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/notifier"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// LinkTelegram handles POST /api/channels/telegram/link. It returns a
+// one-time code and a t.me deep link; sending /start with the code to the
+// bot links that chat to the user's Telegram channel.
+func LinkTelegram(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Verify session and get user ID
+	authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+	if authServiceURL == "" {
+		authServiceURL = "http://localhost:8787"
+	}
+
+	userID, err := auth.VerifySession(r, authServiceURL)
+	if err != nil {
+		log.Printf("Channels: auth error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	link, err := notifier.TelegramLink(ctx, userID)
+	if errors.Is(err, notifier.ErrTelegramDisabled) {
+		http.Error(w, "Telegram is not configured on this server", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Printf("Channels: failed to create Telegram link for user %s: %v", userID, err)
+		http.Error(w, "Failed to create Telegram link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
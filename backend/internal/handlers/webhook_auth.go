@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// webhookRejections counts rejected webhook attempts by reason
+var (
+	webhookRejections   = map[string]int64{}
+	webhookRejectionsMu sync.Mutex
+)
+
+// rejectWebhook logs and counts a rejected webhook and responds 401.
+// The request path is not logged since it may contain a token.
+func rejectWebhook(w http.ResponseWriter, r *http.Request, reason, watchUUID string) {
+	webhookRejectionsMu.Lock()
+	webhookRejections[reason]++
+	count := webhookRejections[reason]
+	webhookRejectionsMu.Unlock()
+
+	log.Printf("Webhook: rejected from %s (reason: %s, watch_uuid: %q, total for reason: %d)", r.RemoteAddr, reason, watchUUID, count)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// WebhookRejections handles GET /api/admin/webhook-rejections
+// Returns the number of rejected webhooks per reason since startup.
+func WebhookRejections(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !verifyAdmin(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	webhookRejectionsMu.Lock()
+	counts := make(map[string]int64, len(webhookRejections))
+	var total int64
+	for reason, n := range webhookRejections {
+		counts[reason] = n
+		total += n
+	}
+	webhookRejectionsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":    total,
+		"byReason": counts,
+	})
+}
@@ -8,22 +8,83 @@ import (
 )
 
 // Alert represents a notification created from a changedetection.io webhook
+// or a change found by the native scheduler
 type Alert struct {
-	ID         primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
-	UserID     string             `json:"userId" bson:"userId"`
-	MonitorID  primitive.ObjectID `json:"monitorId" bson:"monitorId"`
-	Checked    bool               `json:"checked" bson:"checked"`
-	ReceivedAt time.Time          `json:"receivedAt" bson:"receivedAt"`
-	Payload    bson.Raw           `json:"payload" bson:"payload"` // Immutable webhook data
+	ID           primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID       string             `json:"userId" bson:"userId"`
+	MonitorID    primitive.ObjectID `json:"monitorId" bson:"monitorId"`
+	Checked      bool               `json:"checked" bson:"checked"`
+	ReceivedAt   time.Time          `json:"receivedAt" bson:"receivedAt"`
+	AlertDetails `bson:",inline"`
+	Payload      bson.Raw   `json:"payload" bson:"payload"` // Immutable webhook data, kept for audit
+	Archived     bool       `json:"archived,omitempty" bson:"archived,omitempty"`
+	ArchivedAt   *time.Time `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"`
+
+	// Changes folded into this alert during the monitor's alert cooldown
+	Count                 int        `json:"count,omitempty" bson:"count,omitempty"`
+	LastChangeAt          *time.Time `json:"lastChangeAt,omitempty" bson:"lastChangeAt,omitempty"`
+	LastSnapshotTimestamp int64      `json:"lastSnapshotTs,omitempty" bson:"lastSnapshotTs,omitempty"`
+	IdempotencyKeys       []string   `json:"-" bson:"idempotencyKeys,omitempty"` // One per delivery, e.g. "<watch uuid>:<snapshot ts>"
+
+	// Progress through the monitor's escalation policy while unchecked
+	EscalationLevel int        `json:"escalationLevel,omitempty" bson:"escalationLevel,omitempty"` // Steps already notified
+	EscalatedAt     *time.Time `json:"escalatedAt,omitempty" bson:"escalatedAt,omitempty"`
+}
+
+// AlertDetails are the fields extracted from the change notification.
+// Alerts stored before these existed only have the raw payload.
+type AlertDetails struct {
+	Source                    string   `json:"source,omitempty" bson:"source,omitempty"` // "changedetection" or "native"
+	WatchUUID                 string   `json:"watchUuid,omitempty" bson:"watchUuid,omitempty"`
+	WatchURL                  string   `json:"watchUrl,omitempty" bson:"watchUrl,omitempty"`
+	Title                     string   `json:"title,omitempty" bson:"title,omitempty"`
+	Summary                   string   `json:"summary,omitempty" bson:"summary,omitempty"` // e.g. "3 lines added, 1 line removed"
+	Diff                      string   `json:"diff,omitempty" bson:"diff,omitempty"`
+	Added                     []string `json:"added,omitempty" bson:"added,omitempty"`
+	Removed                   []string `json:"removed,omitempty" bson:"removed,omitempty"`
+	TriggeredText             string   `json:"triggeredText,omitempty" bson:"triggeredText,omitempty"`
+	PreviewURL                string   `json:"previewUrl,omitempty" bson:"previewUrl,omitempty"`
+	DiffURL                   string   `json:"diffUrl,omitempty" bson:"diffUrl,omitempty"`
+	SnapshotTimestamp         int64    `json:"snapshotTs,omitempty" bson:"snapshotTs,omitempty"`                 // Snapshot.Timestamp of the new content
+	PreviousSnapshotTimestamp int64    `json:"previousSnapshotTs,omitempty" bson:"previousSnapshotTs,omitempty"` // Snapshot.Timestamp it was compared with
 }
 
 // AlertResponse is the JSON response format for alerts including parsed payload fields
 type AlertResponse struct {
-	ID           primitive.ObjectID `json:"_id"`
-	UserID       string             `json:"userId"`
-	MonitorID    primitive.ObjectID `json:"monitorId"`
-	MonitorName  string             `json:"monitorName,omitempty"`
-	Checked      bool               `json:"checked"`
-	ReceivedAt   time.Time          `json:"receivedAt"`
-	Payload      map[string]any     `json:"payload"`
+	ID          primitive.ObjectID `json:"_id"`
+	UserID      string             `json:"userId"`
+	MonitorID   primitive.ObjectID `json:"monitorId"`
+	MonitorName string             `json:"monitorName,omitempty"`
+	Checked     bool               `json:"checked"`
+	ReceivedAt  time.Time          `json:"receivedAt"`
+	AlertDetails
+	Count                 int            `json:"count,omitempty"`
+	LastChangeAt          *time.Time     `json:"lastChangeAt,omitempty"`
+	LastSnapshotTimestamp int64          `json:"lastSnapshotTs,omitempty"`
+	EscalationLevel       int            `json:"escalationLevel,omitempty"`
+	Payload               map[string]any `json:"payload"`
+}
+
+// AlertListResponse is one page of GET /api/alerts
+type AlertListResponse struct {
+	Alerts     []AlertResponse `json:"alerts"`
+	NextCursor string          `json:"nextCursor,omitempty"` // Empty on the last page
+	Total      int64           `json:"total"`
+	Unread     int64           `json:"unread"`
+}
+
+// AlertFilter selects alerts like the ListAlerts query params
+type AlertFilter struct {
+	MonitorID string `json:"monitorId,omitempty"`
+	Checked   *bool  `json:"checked,omitempty"`
+	Archived  bool   `json:"archived,omitempty"` // Match archived alerts instead of visible ones
+	From      string `json:"from,omitempty"`     // RFC 3339
+	To        string `json:"to,omitempty"`       // RFC 3339
+}
+
+// BulkAlertRequest is the body of POST /api/alerts/bulk
+type BulkAlertRequest struct {
+	Action string       `json:"action"` // check, uncheck, archive, unarchive or delete
+	IDs    []string     `json:"ids,omitempty"`
+	Filter *AlertFilter `json:"filter,omitempty"`
 }
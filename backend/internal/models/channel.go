@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Channel is a destination a user configured for one notification type,
+// such as a Slack or Discord incoming webhook or their own HTTP endpoint
+type Channel struct {
+	ID         primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID     string             `json:"userId" bson:"userId"`
+	Type       string             `json:"type" bson:"type"` // slack, discord, telegram, webhook
+	Name       string             `json:"name" bson:"name"`
+	WebhookURL string             `json:"webhookUrl,omitempty" bson:"webhookUrl,omitempty"` // Masked in API responses
+	Enabled    bool               `json:"enabled" bson:"enabled"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt" bson:"updatedAt"`
+
+	// Outbound webhooks are signed with Secret, which is only returned
+	// when the channel is created or the secret is rotated
+	Secret string `json:"secret,omitempty" bson:"secret,omitempty"`
+
+	// Telegram chats are linked by sending the bot /start <LinkCode>
+	ChatID        int64      `json:"chatId,omitempty" bson:"chatId,omitempty"`
+	LinkCode      string     `json:"-" bson:"linkCode,omitempty"`
+	LinkExpiresAt *time.Time `json:"linkExpiresAt,omitempty" bson:"linkExpiresAt,omitempty"`
+}
+
+// ChannelRequest creates or updates a Channel. Empty fields are left
+// unchanged on update.
+type ChannelRequest struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhookUrl"`
+	Enabled    *bool  `json:"enabled,omitempty"`
+}
+
+// TelegramLinkResponse tells the user how to link a Telegram chat
+type TelegramLinkResponse struct {
+	ChannelID primitive.ObjectID `json:"channelId"`
+	Code      string             `json:"code"`
+	Link      string             `json:"link,omitempty"` // t.me deep link, when the bot username is known
+	ExpiresAt time.Time          `json:"expiresAt"`
+}
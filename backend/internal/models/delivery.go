@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery tracks sending one alert over one notification channel
+type Delivery struct {
+	ID        primitive.ObjectID  `json:"_id" bson:"_id,omitempty"`
+	AlertID   primitive.ObjectID  `json:"alertId" bson:"alertId"`
+	MonitorID primitive.ObjectID  `json:"monitorId" bson:"monitorId"`
+	UserID    string              `json:"userId" bson:"userId"`
+	Channel   string              `json:"channel" bson:"channel"`                         // Notifier name, e.g. "email"
+	ChannelID *primitive.ObjectID `json:"channelId,omitempty" bson:"channelId,omitempty"` // User channel, for slack, discord, telegram and webhook
+	Status    string              `json:"status" bson:"status"`                           // deferred, pending, sending, sent, failed, dead
+	Attempts  []DeliveryAttempt   `json:"attempts" bson:"attempts"`
+	CreatedAt time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt" bson:"updatedAt"`
+	SentAt    *time.Time          `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+	ClaimedAt *time.Time          `json:"-" bson:"claimedAt,omitempty"` // When a worker started sending
+
+	// Failed deliveries are retried with exponential backoff until they
+	// run out of attempts and are moved to the dead-letter list
+	Failures      int        `json:"failures" bson:"failures"`                               // Consecutive failed attempts, reset on manual retry
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty"` // Also when a deferred delivery's window opens
+
+	Escalation int `json:"escalation,omitempty" bson:"escalation,omitempty"` // Escalation step that sent it, from 1; 0 for the alert's own notification
+}
+
+// DeliveryAttempt is the outcome of one try at sending a delivery
+type DeliveryAttempt struct {
+	At           time.Time `json:"at" bson:"at"`
+	DurationMs   int64     `json:"durationMs" bson:"durationMs"`
+	Error        string    `json:"error,omitempty" bson:"error,omitempty"`
+	StatusCode   int       `json:"statusCode,omitempty" bson:"statusCode,omitempty"`     // HTTP status from webhook endpoints
+	ResponseBody string    `json:"responseBody,omitempty" bson:"responseBody,omitempty"` // Truncated response from webhook endpoints
+}
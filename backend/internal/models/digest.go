@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Digest is one scheduled summary of a user's alerts over one channel.
+// It is unique per user, channel and ScheduledFor, so each run is sent
+// once even when the server restarts.
+type Digest struct {
+	ID           primitive.ObjectID   `json:"_id" bson:"_id,omitempty"`
+	UserID       string               `json:"userId" bson:"userId"`
+	Frequency    string               `json:"frequency" bson:"frequency"` // daily, weekly, or quiet for alerts held during quiet hours
+	Channel      string               `json:"channel" bson:"channel"`
+	ChannelID    *primitive.ObjectID  `json:"channelId,omitempty" bson:"channelId,omitempty"`
+	ScheduledFor time.Time            `json:"scheduledFor" bson:"scheduledFor"` // End of the period
+	PeriodStart  time.Time            `json:"periodStart" bson:"periodStart"`
+	Status       string               `json:"status" bson:"status"`                         // deferred, pending, sending, sent, failed, dead, empty
+	AlertIDs     []primitive.ObjectID `json:"alertIds,omitempty" bson:"alertIds,omitempty"` // Alerts held during quiet hours
+	AlertCount   int                  `json:"alertCount" bson:"alertCount"`
+	Error        string               `json:"error,omitempty" bson:"error,omitempty"` // Last failure
+	CreatedAt    time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time            `json:"updatedAt" bson:"updatedAt"`
+	SentAt       *time.Time           `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+	ClaimedAt    *time.Time           `json:"-" bson:"claimedAt,omitempty"`
+
+	Failures      int        `json:"failures" bson:"failures"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EscalationPolicy notifies further channels about alerts that stay
+// unchecked. The monitor's own notification method is the first step;
+// checking the alert stops the chain.
+type EscalationPolicy struct {
+	ID        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID    string             `json:"userId" bson:"userId"`
+	Name      string             `json:"name" bson:"name"`
+	Steps     []EscalationStep   `json:"steps" bson:"steps"` // In order of AfterMinutes
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// EscalationStep notifies one channel once an alert has gone unchecked
+// for AfterMinutes
+type EscalationStep struct {
+	AfterMinutes int                 `json:"afterMinutes" bson:"afterMinutes"`               // Since the alert was first notified
+	Channel      string              `json:"channel" bson:"channel"`                         // Notifier name, e.g. "email" or "slack"
+	ChannelID    *primitive.ObjectID `json:"channelId,omitempty" bson:"channelId,omitempty"` // One user channel; unset sends to all of Channel's
+}
+
+// EscalationPolicyRequest creates or updates an EscalationPolicy. Empty
+// fields are left unchanged on update.
+type EscalationPolicyRequest struct {
+	Name  string           `json:"name"`
+	Steps []EscalationStep `json:"steps,omitempty"`
+}
@@ -24,11 +24,26 @@ type Monitor struct {
 	AlertsEnabled       bool               `json:"alertsEnabled" bson:"alertsEnabled"`
 	NotificationMethod  string             `json:"notificationMethod,omitempty" bson:"notificationMethod,omitempty"`
 	DetectionMode       string             `json:"detectionMode,omitempty" bson:"detectionMode,omitempty"`
+	ContentHash         string             `json:"contentHash,omitempty" bson:"contentHash,omitempty"` // Hash of the last natively checked content
+	LastError           string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CheckFailures       int                `json:"checkFailures,omitempty" bson:"checkFailures,omitempty"` // Consecutive failed native checks, which back off the interval
+	StoppedReason       string             `json:"stoppedReason,omitempty" bson:"stoppedReason,omitempty"` // Why the monitor was paused automatically
+	StoppedAt           *time.Time         `json:"stoppedAt,omitempty" bson:"stoppedAt,omitempty"`
+	WebhookToken        string             `json:"-" bson:"webhookToken,omitempty"`                            // Secret in this monitor's webhook URL
+	AlertCooldown       int                `json:"alertCooldown,omitempty" bson:"alertCooldown,omitempty"`     // Minutes in which further changes join the last alert; 0 disables
+	DigestFrequency     string             `json:"digestFrequency,omitempty" bson:"digestFrequency,omitempty"` // immediate, daily or weekly; empty follows the user's settings
+
+	// Overrides the user's notification schedule, e.g. to let urgent
+	// monitors alert during quiet hours
+	Schedule *NotificationSchedule `json:"schedule,omitempty" bson:"schedule,omitempty"`
+
+	// Notifies further channels while this monitor's alerts stay unchecked
+	EscalationPolicyID *primitive.ObjectID `json:"escalationPolicyId,omitempty" bson:"escalationPolicyId,omitempty"`
 }
 
 type Frequency struct {
 	Value int    `json:"value" bson:"value"`
-	Unit  string `json:"unit" bson:"unit"` // minutes, hours
+	Unit  string `json:"unit" bson:"unit"` // minutes, hours, days
 }
 
 type Duration struct {
@@ -40,10 +55,21 @@ type CreateMonitorRequest struct {
 	WebsiteName        string    `json:"websiteName"`
 	TargetType         string    `json:"targetType"`
 	URL                string    `json:"url"`
-	Selector           string    `json:"selector,omitempty"`
+	Selector           *string   `json:"selector,omitempty"` // "" removes the selector
 	Frequency          Frequency `json:"frequency,omitempty"`
 	Duration           Duration  `json:"duration,omitempty"`
 	AlertsEnabled      bool      `json:"alertsEnabled"`
 	NotificationMethod string    `json:"notificationMethod,omitempty"`
 	DetectionMode      string    `json:"detectionMode,omitempty"`
+	AlertCooldown      *int      `json:"alertCooldown,omitempty"`   // Minutes; 0 turns the cooldown off
+	DigestFrequency    *string   `json:"digestFrequency,omitempty"` // "" follows the user's settings
+
+	Schedule *NotificationSchedule `json:"schedule,omitempty"` // Mode "" follows the user's schedule
+
+	EscalationPolicyID *string `json:"escalationPolicyId,omitempty"` // "" removes the policy
+}
+
+// BulkMonitorRequest selects several monitors for a bulk action
+type BulkMonitorRequest struct {
+	IDs []string `json:"ids"`
 }
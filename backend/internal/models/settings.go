@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationSettings are a user's preferences for when alerts are sent.
+// Users without a stored document get the defaults.
+type NotificationSettings struct {
+	UserID          string                `json:"userId" bson:"_id"`
+	Timezone        string                `json:"timezone" bson:"timezone"`                     // IANA name, e.g. "Europe/Berlin"
+	DigestFrequency string                `json:"digestFrequency" bson:"digestFrequency"`       // immediate, daily or weekly; monitors may override it
+	DigestTime      string                `json:"digestTime" bson:"digestTime"`                 // Local "HH:MM" digests are sent at
+	DigestWeekday   int                   `json:"digestWeekday" bson:"digestWeekday"`           // Day of weekly digests, 0 is Sunday
+	Schedule        *NotificationSchedule `json:"schedule,omitempty" bson:"schedule,omitempty"` // When alerts may be sent; monitors may override it
+	UpdatedAt       time.Time             `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NotificationSettingsRequest updates NotificationSettings. Omitted fields
+// are left unchanged.
+type NotificationSettingsRequest struct {
+	Timezone        *string               `json:"timezone,omitempty"`
+	DigestFrequency *string               `json:"digestFrequency,omitempty"`
+	DigestTime      *string               `json:"digestTime,omitempty"`
+	DigestWeekday   *int                  `json:"digestWeekday,omitempty"`
+	Schedule        *NotificationSchedule `json:"schedule,omitempty"`
+}
+
+// NotificationSchedule limits when alerts are sent. Alerts arriving
+// outside its windows (quiet hours) are stored as usual but held back
+// until the next window opens.
+type NotificationSchedule struct {
+	Mode        string           `json:"mode" bson:"mode"`                                   // always or windows; empty on a monitor follows the user's schedule
+	Timezone    string           `json:"timezone,omitempty" bson:"timezone,omitempty"`       // IANA name; empty uses the user's timezone
+	Windows     []ScheduleWindow `json:"windows,omitempty" bson:"windows,omitempty"`         // When alerts are sent in windows mode
+	QuietAction string           `json:"quietAction,omitempty" bson:"quietAction,omitempty"` // defer (send each alert when the window opens) or digest (send one summary)
+}
+
+// ScheduleWindow is a daily span in which alerts are sent
+type ScheduleWindow struct {
+	Days  []int  `json:"days,omitempty" bson:"days,omitempty"` // 0 is Sunday; empty means every day
+	Start string `json:"start" bson:"start"`                   // Local "HH:MM"
+	End   string `json:"end" bson:"end"`                       // Local "HH:MM"; at or before Start spans midnight
+}
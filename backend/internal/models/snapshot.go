@@ -0,0 +1,41 @@
+package models
+
+import (
+	"justping/backend/internal/diff"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Snapshot records what a monitored page looked like at one check
+type Snapshot struct {
+	ID          primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	UserID      string             `json:"userId" bson:"userId"`
+	MonitorID   primitive.ObjectID `json:"monitorId" bson:"monitorId"`
+	Timestamp   int64              `json:"timestamp" bson:"timestamp"` // Unix seconds, used as :ts in the API
+	CheckedAt   time.Time          `json:"checkedAt" bson:"checkedAt"`
+	Source      string             `json:"source" bson:"source"` // native, changedetection
+	Content     string             `json:"content,omitempty" bson:"content"`
+	ContentHash string             `json:"contentHash,omitempty" bson:"contentHash,omitempty"`
+	StatusCode  int                `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs  int64              `json:"durationMs,omitempty" bson:"durationMs,omitempty"`
+	Headers     map[string]string  `json:"headers,omitempty" bson:"headers,omitempty"`
+}
+
+// SnapshotRef identifies one side of a diff
+type SnapshotRef struct {
+	Timestamp   int64     `json:"timestamp"`
+	CheckedAt   time.Time `json:"checkedAt"`
+	ContentHash string    `json:"contentHash,omitempty"`
+}
+
+// DiffResponse is the JSON response for GET /api/monitors/:id/diff
+type DiffResponse struct {
+	From    SnapshotRef `json:"from"`
+	To      SnapshotRef `json:"to"`
+	Stats   diff.Stats  `json:"stats"`
+	Unified string      `json:"unified"`
+	Words   []diff.Op   `json:"words"`
+	HTML    string      `json:"html"`
+}
@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Channel types configured per user
+const (
+	ChannelSlack    = "slack"
+	ChannelDiscord  = "discord"
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook" // The user's own endpoint, sent signed JSON
+)
+
+// chatNotifier posts alerts to every enabled channel of one type that the
+// monitor's owner configured, e.g. all their Slack incoming webhooks
+type chatNotifier struct {
+	channelType  string
+	render       func(n Notification) ([]byte, error)
+	renderDigest func(d DigestNotification) ([]byte, error)
+}
+
+func (c *chatNotifier) Name() string {
+	return c.channelType
+}
+
+func (c *chatNotifier) Send(ctx context.Context, n Notification) error {
+	return sendToUserChannels(ctx, c, c.channelType, n)
+}
+
+func (c *chatNotifier) sendTo(ctx context.Context, channel models.Channel, n Notification) error {
+	body, err := c.render(n)
+	if err != nil {
+		return fmt.Errorf("render %s message: %w", c.channelType, err)
+	}
+	return postJSON(ctx, channel.WebhookURL, body)
+}
+
+func (c *chatNotifier) sendDigestTo(ctx context.Context, channel models.Channel, d DigestNotification) error {
+	body, err := c.renderDigest(d)
+	if err != nil {
+		return fmt.Errorf("render %s digest: %w", c.channelType, err)
+	}
+	return postJSON(ctx, channel.WebhookURL, body)
+}
+
+// channelSender delivers to one user-configured channel
+type channelSender interface {
+	sendTo(ctx context.Context, channel models.Channel, n Notification) error
+}
+
+// sendToUserChannels sends n to every enabled channel of channelType that
+// belongs to the monitor's owner, failing if any of them fails
+func sendToUserChannels(ctx context.Context, sender channelSender, channelType string, n Notification) error {
+	channels, err := userChannels(ctx, n.Monitor.UserID, channelType)
+	if err != nil {
+		return fmt.Errorf("load %s channels: %w", channelType, err)
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("no %s channel configured", channelType)
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		if err := sender.sendTo(ctx, channel, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// userChannels returns a user's enabled channels of one type
+func userChannels(ctx context.Context, userID, channelType string) ([]models.Channel, error) {
+	cursor, err := database.GetChannelsCollection().Find(ctx, bson.M{
+		"userId":  userID,
+		"type":    channelType,
+		"enabled": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var channels []models.Channel
+	if err := cursor.All(ctx, &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// postJSON sends body to an incoming webhook, treating any 2xx as success
+func postJSON(ctx context.Context, target string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			return fmt.Errorf("webhook returned %d (retry after %ss): %s", resp.StatusCode, retryAfter, strings.TrimSpace(string(respBody)))
+		}
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// chatWebhookHosts are the hosts each chat channel's webhooks live on
+var chatWebhookHosts = map[string][]string{
+	ChannelSlack:   {"hooks.slack.com"},
+	ChannelDiscord: {"discord.com", "discordapp.com", "canary.discord.com", "ptb.discord.com"},
+}
+
+// ValidateChannel checks a channel type and its webhook URL
+func ValidateChannel(channelType, webhookURL string) error {
+	if channelType == ChannelTelegram {
+		return fmt.Errorf("telegram chats are linked through /api/channels/telegram/link")
+	}
+	if channelType == ChannelWebhook {
+		return validateEndpoint(webhookURL)
+	}
+	hosts, ok := chatWebhookHosts[channelType]
+	if !ok {
+		return fmt.Errorf("unsupported channel type %q", channelType)
+	}
+
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must be an https URL")
+	}
+	for _, host := range hosts {
+		if u.Host == host {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook URL must be on %s", strings.Join(hosts, " or "))
+}
+
+// SendTest sends a sample alert to one channel so users can check its setup
+func SendTest(ctx context.Context, channel models.Channel) error {
+	n, ok := lookup(channel.Type)
+	if !ok {
+		return ErrNoNotifier
+	}
+	sender, ok := n.(channelSender)
+	if !ok {
+		return fmt.Errorf("channel type %q cannot be tested", channel.Type)
+	}
+	return sender.sendTo(ctx, channel, sampleNotification(channel.UserID))
+}
+
+// sampleNotification is a made-up alert used for test messages
+func sampleNotification(userID string) Notification {
+	return Notification{
+		Alert: models.Alert{
+			ID:         primitive.NewObjectID(),
+			UserID:     userID,
+			ReceivedAt: time.Now(),
+			AlertDetails: models.AlertDetails{
+				WatchURL: "https://example.com/pricing",
+				Title:    "Example pricing page",
+				Summary:  "1 line added, 1 line removed",
+				Added:    []string{"Pro plan: $12/month"},
+				Removed:  []string{"Pro plan: $10/month"},
+			},
+		},
+		Monitor: models.Monitor{
+			UserID:      userID,
+			WebsiteName: "JustPing test notification",
+			URL:         "https://example.com/pricing",
+		},
+		Test: true,
+	}
+}
@@ -0,0 +1,285 @@
+package notifier
+
+import (
+	"encoding/json"
+	"justping/backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chatNotification builds an alert notification for the render tests
+func chatNotification(added, removed []string) Notification {
+	id, _ := primitive.ObjectIDFromHex("65f000000000000000000001")
+	return Notification{
+		Alert: models.Alert{
+			ID:         id,
+			ReceivedAt: time.Date(2026, time.March, 4, 9, 30, 0, 0, time.UTC),
+			AlertDetails: models.AlertDetails{
+				WatchURL: "https://example.com/pricing",
+				Summary:  "1 line added",
+				Added:    added,
+				Removed:  removed,
+			},
+		},
+		Monitor: models.Monitor{WebsiteName: "Pricing", URL: "https://example.com/pricing"},
+	}
+}
+
+// manyLines returns n lines of the given length
+func manyLines(n, length int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", length)
+	}
+	return lines
+}
+
+type slackMessage struct {
+	Text   string `json:"text"`
+	Blocks []struct {
+		Type string `json:"type"`
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+		Elements []struct {
+			Text any    `json:"text"`
+			URL  string `json:"url"`
+		} `json:"elements"`
+	} `json:"blocks"`
+}
+
+func TestRenderSlack(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://app.example/")
+	link := "https://app.example/navigate/alerts?alert=65f000000000000000000001"
+
+	escalated := chatNotification([]string{"Pro plan: $12"}, nil)
+	escalated.Escalation = 1
+
+	tests := []struct {
+		name        string
+		n           Notification
+		wantHeader  string
+		wantSnippet []string // substrings of the snippet block, nil for none
+		wantNoText  string
+	}{
+		{
+			name:        "added and removed lines",
+			n:           chatNotification([]string{"Pro plan: $12"}, []string{"Pro plan: $10"}),
+			wantHeader:  "Change detected: Pricing",
+			wantSnippet: []string{"```+ Pro plan: $12\n- Pro plan: $10```"},
+		},
+		{
+			name:       "no changed lines",
+			n:          chatNotification(nil, nil),
+			wantHeader: "Change detected: Pricing",
+		},
+		{
+			name:        "markup is escaped",
+			n:           chatNotification([]string{"<!channel> & <b>"}, nil),
+			wantHeader:  "Change detected: Pricing",
+			wantSnippet: []string{"+ &lt;!channel&gt; &amp; &lt;b&gt;"},
+			wantNoText:  "<!channel>",
+		},
+		{
+			name:        "excerpt is capped",
+			n:           chatNotification(manyLines(25, 10), nil),
+			wantHeader:  "Change detected: Pricing",
+			wantSnippet: []string{"… and 5 more changed lines"},
+		},
+		{
+			name:        "long snippet is truncated",
+			n:           chatNotification(manyLines(20, 500), nil),
+			wantHeader:  "Change detected: Pricing",
+			wantSnippet: []string{"…```"},
+		},
+		{
+			name:        "escalation",
+			n:           escalated,
+			wantHeader:  "Still unchecked: Pricing",
+			wantSnippet: []string{"+ Pro plan: $12"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := renderSlack(tt.n)
+			if err != nil {
+				t.Fatalf("renderSlack: %v", err)
+			}
+			if tt.wantNoText != "" && strings.Contains(string(body), tt.wantNoText) {
+				t.Errorf("message contains unescaped %q", tt.wantNoText)
+			}
+
+			var msg slackMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if want := "Change detected: Pricing (1 line added)"; msg.Text != want {
+				t.Errorf("fallback text = %q, want %q", msg.Text, want)
+			}
+
+			var snippet, button string
+			header := ""
+			for i, block := range msg.Blocks {
+				switch {
+				case block.Type == "header":
+					header = block.Text.Text
+				case block.Type == "section" && i > 1:
+					snippet = block.Text.Text
+				case block.Type == "actions":
+					button = block.Elements[0].URL
+				}
+				if len(block.Text.Text) > slackMaxText {
+					t.Errorf("block %d has %d characters, Slack allows %d", i, len(block.Text.Text), slackMaxText)
+				}
+			}
+			if header != tt.wantHeader {
+				t.Errorf("header = %q, want %q", header, tt.wantHeader)
+			}
+			if button != link {
+				t.Errorf("button URL = %q, want %q", button, link)
+			}
+			if tt.wantSnippet == nil && snippet != "" {
+				t.Errorf("unexpected snippet %q", snippet)
+			}
+			for _, want := range tt.wantSnippet {
+				if !strings.Contains(snippet, want) {
+					t.Errorf("snippet %q does not contain %q", snippet, want)
+				}
+			}
+		})
+	}
+}
+
+type discordMessage struct {
+	Username string `json:"username"`
+	Embeds   []struct {
+		Title       string `json:"title"`
+		URL         string `json:"url"`
+		Description string `json:"description"`
+		Timestamp   string `json:"timestamp"`
+	} `json:"embeds"`
+}
+
+func TestRenderDiscord(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://app.example")
+	link := "\n[View alert](https://app.example/navigate/alerts?alert=65f000000000000000000001)"
+
+	longName := chatNotification([]string{"a"}, nil)
+	longName.Monitor.WebsiteName = strings.Repeat("n", 300)
+	longSummary := chatNotification(nil, nil)
+	longSummary.Alert.Summary = strings.Repeat("s", 5000)
+
+	tests := []struct {
+		name      string
+		n         Notification
+		wantParts []string
+	}{
+		{
+			name:      "added and removed lines",
+			n:         chatNotification([]string{"Pro plan: $12"}, []string{"Pro plan: $10"}),
+			wantParts: []string{"1 line added\n```diff\n+ Pro plan: $12\n- Pro plan: $10\n```"},
+		},
+		{
+			name:      "no changed lines",
+			n:         chatNotification(nil, nil),
+			wantParts: []string{"1 line added" + link},
+		},
+		{
+			name:      "code fences in lines are defused",
+			n:         chatNotification([]string{"```js"}, nil),
+			wantParts: []string{"+ '''js"},
+		},
+		{
+			name:      "long snippet keeps the link",
+			n:         chatNotification(manyLines(20, 500), nil),
+			wantParts: []string{"…"},
+		},
+		{
+			name:      "long summary keeps the link",
+			n:         longSummary,
+			wantParts: []string{"…"},
+		},
+		{
+			name: "long title",
+			n:    longName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := renderDiscord(tt.n)
+			if err != nil {
+				t.Fatalf("renderDiscord: %v", err)
+			}
+			var msg discordMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(msg.Embeds) != 1 {
+				t.Fatalf("got %d embeds, want 1", len(msg.Embeds))
+			}
+			embed := msg.Embeds[0]
+
+			if len(embed.Title) > discordMaxTitle {
+				t.Errorf("title has %d characters, Discord allows %d", len(embed.Title), discordMaxTitle)
+			}
+			if len(embed.Description) > discordMaxDescription {
+				t.Errorf("description has %d characters, Discord allows %d", len(embed.Description), discordMaxDescription)
+			}
+			if !strings.HasSuffix(embed.Description, link) {
+				t.Errorf("description does not end with the alert link: %q", embed.Description[max(len(embed.Description)-100, 0):])
+			}
+			if embed.URL != "https://example.com/pricing" {
+				t.Errorf("url = %q", embed.URL)
+			}
+			if embed.Timestamp != "2026-03-04T09:30:00Z" {
+				t.Errorf("timestamp = %q", embed.Timestamp)
+			}
+			for _, want := range tt.wantParts {
+				if !strings.Contains(embed.Description, want) {
+					t.Errorf("description does not contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateChannel(t *testing.T) {
+	tests := []struct {
+		name        string
+		channelType string
+		url         string
+		wantErr     bool
+	}{
+		{"slack", ChannelSlack, "https://hooks.slack.com/services/T0/B0/x", false},
+		{"discord", ChannelDiscord, "https://discord.com/api/webhooks/1/x", false},
+		{"discordapp", ChannelDiscord, "https://discordapp.com/api/webhooks/1/x", false},
+		{"discord canary", ChannelDiscord, "https://canary.discord.com/api/webhooks/1/x", false},
+		{"slack over http", ChannelSlack, "http://hooks.slack.com/services/T0/B0/x", true},
+		{"slack on another host", ChannelSlack, "https://example.com/services/T0/B0/x", true},
+		{"slack host as a prefix", ChannelSlack, "https://hooks.slack.com.example.com/x", true},
+		{"slack host as userinfo", ChannelSlack, "https://hooks.slack.com@example.com/x", true},
+		{"slack with a port", ChannelSlack, "https://hooks.slack.com:8443/x", true},
+		{"slack URL for discord", ChannelDiscord, "https://hooks.slack.com/services/T0/B0/x", true},
+		{"discord URL for slack", ChannelSlack, "https://discord.com/api/webhooks/1/x", true},
+		{"discord subdomain", ChannelDiscord, "https://evil.discord.com/api/webhooks/1/x", true},
+		{"internal address", ChannelDiscord, "https://127.0.0.1/api/webhooks/1/x", true},
+		{"not a URL", ChannelSlack, "hooks.slack.com/services", true},
+		{"empty", ChannelSlack, "", true},
+		{"telegram", ChannelTelegram, "https://api.telegram.org/", true},
+		{"unknown type", "teams", "https://example.webhook.office.com/x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChannel(tt.channelType, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateChannel(%q, %q) = %v, wantErr %t", tt.channelType, tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,583 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Digest frequencies. Monitors without their own follow the user's.
+const (
+	DigestImmediate = "immediate" // One notification per change
+	DigestDaily     = "daily"
+	DigestWeekly    = "weekly"
+)
+
+// StatusEmpty marks a digest whose period had no alerts, so nothing was sent
+const StatusEmpty = "empty"
+
+const (
+	// digestInterval is how often due digests are scheduled and sent
+	digestInterval = time.Minute
+	// digestGrace is how late a digest may still be scheduled, e.g. after
+	// downtime. Later runs are skipped and the next one covers their alerts.
+	digestGrace = time.Hour
+	// maxDigestAlerts caps the alerts one digest includes
+	maxDigestAlerts = 200
+)
+
+// DigestNotification is what a digest delivers: the alerts of a user's
+// monitors over one period, grouped by monitor
+type DigestNotification struct {
+	ID          string // The digest's record, the same on every retry
+	UserID      string
+	Frequency   string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Location    *time.Location // The user's timezone, for display
+	Monitors    []DigestMonitor
+}
+
+// DigestMonitor is one monitor's part of a digest
+type DigestMonitor struct {
+	Monitor models.Monitor
+	Alerts  []models.Alert // Newest first
+	Changes int            // Changes across Alerts, counting collapsed ones
+}
+
+// Changes counts the changes across all monitors of the digest
+func (d DigestNotification) Changes() int {
+	total := 0
+	for _, m := range d.Monitors {
+		total += m.Changes
+	}
+	return total
+}
+
+// alerts returns every alert in the digest
+func (d DigestNotification) alerts() []models.Alert {
+	var alerts []models.Alert
+	for _, m := range d.Monitors {
+		alerts = append(alerts, m.Alerts...)
+	}
+	return alerts
+}
+
+// digestSender is implemented by notifiers that send digests to the user
+// directly, such as email
+type digestSender interface {
+	SendDigest(ctx context.Context, d DigestNotification) error
+}
+
+// digestChannelSender is implemented by notifiers that send digests to
+// one user-configured channel
+type digestChannelSender interface {
+	sendDigestTo(ctx context.Context, channel models.Channel, d DigestNotification) error
+}
+
+// ValidDigestFrequency reports whether f can be stored as a digest
+// frequency. An empty frequency is only valid on monitors.
+func ValidDigestFrequency(f string) bool {
+	return f == DigestImmediate || f == DigestDaily || f == DigestWeekly
+}
+
+// DefaultSettings are the settings of users who never saved any
+func DefaultSettings(userID string) models.NotificationSettings {
+	return models.NotificationSettings{
+		UserID:          userID,
+		Timezone:        "UTC",
+		DigestFrequency: DigestImmediate,
+		DigestTime:      "09:00",
+		DigestWeekday:   int(time.Monday),
+	}
+}
+
+// UserSettings returns a user's notification settings, or the defaults
+func UserSettings(ctx context.Context, userID string) (models.NotificationSettings, error) {
+	settings := DefaultSettings(userID)
+	err := database.GetSettingsCollection().FindOne(ctx, bson.M{"_id": userID}).Decode(&settings)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return settings, err
+	}
+	return settings, nil
+}
+
+// settingsLocation returns the user's timezone, falling back to UTC
+func settingsLocation(s models.NotificationSettings) *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// digestFrequency returns how a monitor's alerts are sent: immediately or
+// in a daily or weekly digest
+func digestFrequency(m models.Monitor, settings models.NotificationSettings) string {
+	if m.DigestFrequency != "" {
+		return m.DigestFrequency
+	}
+	return settings.DigestFrequency
+}
+
+// lastDigestSlot returns the latest time at or before now that a digest
+// of frequency is scheduled for, in the user's timezone
+func lastDigestSlot(now time.Time, s models.NotificationSettings, frequency string) time.Time {
+	at, err := time.Parse("15:04", s.DigestTime)
+	if err != nil {
+		at, _ = time.Parse("15:04", DefaultSettings("").DigestTime)
+	}
+
+	local := now.In(settingsLocation(s))
+	slot := time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, local.Location())
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if frequency == DigestWeekly {
+		slot = slot.AddDate(0, 0, -((int(slot.Weekday()) - s.DigestWeekday + 7) % 7))
+	}
+	return slot
+}
+
+// digestPeriodStart is where the digest period ending at slot starts
+func digestPeriodStart(slot time.Time, frequency string) time.Time {
+	if frequency == DigestWeekly {
+		return slot.AddDate(0, 0, -7)
+	}
+	return slot.AddDate(0, 0, -1)
+}
+
+// runDigests schedules digests that came due and sends pending ones
+func runDigests() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	userIDs, err := digestUsers(ctx)
+	if err != nil {
+		log.Printf("[notifier] Failed to find digest users: %v", err)
+	}
+	for _, userID := range userIDs {
+		if err := scheduleDigests(ctx, userID, now); err != nil {
+			log.Printf("[notifier] Failed to schedule digests for user %s: %v", userID, err)
+		}
+	}
+
+	cursor, err := database.GetDigestsCollection().Find(ctx,
+		bson.M{"$or": claimable(now)},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		log.Printf("[notifier] Failed to query pending digests: %v", err)
+		return
+	}
+	var pending []models.Digest
+	if err := cursor.All(ctx, &pending); err != nil {
+		log.Printf("[notifier] Failed to decode pending digests: %v", err)
+		return
+	}
+	for _, d := range pending {
+		sendDigest(d.ID)
+	}
+}
+
+// digestUsers returns the users with digests turned on in their settings
+// or on any of their monitors
+func digestUsers(ctx context.Context) ([]string, error) {
+	digested := bson.M{"$in": bson.A{DigestDaily, DigestWeekly}}
+
+	fromSettings, err := database.GetSettingsCollection().Distinct(ctx, "_id", bson.M{"digestFrequency": digested})
+	if err != nil {
+		return nil, err
+	}
+	fromMonitors, err := database.GetMonitorsCollection().Distinct(ctx, "userId", bson.M{"digestFrequency": digested})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var userIDs []string
+	for _, v := range append(fromSettings, fromMonitors...) {
+		if id, ok := v.(string); ok && !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs, nil
+}
+
+// scheduleDigests records the digests that came due for a user, one per
+// channel their digested monitors notify. Recording is idempotent: the
+// unique index on digests makes a second attempt a no-op.
+func scheduleDigests(ctx context.Context, userID string, now time.Time) error {
+	settings, err := UserSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	due := map[string]time.Time{}
+	for _, frequency := range []string{DigestDaily, DigestWeekly} {
+		if slot := lastDigestSlot(now, settings, frequency); now.Sub(slot) <= digestGrace {
+			due[frequency] = slot
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	monitors, err := digestMonitors(ctx, userID, settings)
+	if err != nil {
+		return err
+	}
+
+	for frequency, slot := range due {
+		channels := map[string]bool{}
+		for _, m := range monitors[frequency] {
+			for _, channel := range channelsFor(m) {
+				channels[channel] = true
+			}
+		}
+		for channel := range channels {
+			for _, channelID := range digestChannelIDs(ctx, userID, channel) {
+				if err := recordDigest(ctx, userID, frequency, channel, channelID, slot); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// digestMonitors returns a user's monitors with alerts on, keyed by their
+// digest frequency
+func digestMonitors(ctx context.Context, userID string, settings models.NotificationSettings) (map[string][]models.Monitor, error) {
+	cursor, err := database.GetMonitorsCollection().Find(ctx, bson.M{
+		"userId":             userID,
+		"alertsEnabled":      true,
+		"notificationMethod": bson.M{"$nin": bson.A{nil, ""}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		return nil, err
+	}
+
+	byFrequency := map[string][]models.Monitor{}
+	for _, m := range monitors {
+		frequency := digestFrequency(m, settings)
+		byFrequency[frequency] = append(byFrequency[frequency], m)
+	}
+	return byFrequency, nil
+}
+
+// digestChannelIDs returns the user channels a digest over channel goes
+// to, or a single nil for channels without per-user configuration
+func digestChannelIDs(ctx context.Context, userID, channel string) []*primitive.ObjectID {
+	n, ok := lookup(channel)
+	if _, perChannel := n.(digestChannelSender); !ok || !perChannel {
+		return []*primitive.ObjectID{nil}
+	}
+
+	channels, err := userChannels(ctx, userID, channel)
+	if err != nil {
+		log.Printf("[notifier] Failed to load %s channels for user %s: %v", channel, userID, err)
+	}
+	if len(channels) == 0 {
+		// Sending reports the missing channel on the digest
+		return []*primitive.ObjectID{nil}
+	}
+
+	ids := make([]*primitive.ObjectID, 0, len(channels))
+	for _, c := range channels {
+		id := c.ID
+		ids = append(ids, &id)
+	}
+	return ids
+}
+
+// recordDigest inserts a pending digest for slot unless one exists. Its
+// period starts where the previous digest to the same channel ended, so
+// runs skipped during downtime are covered.
+func recordDigest(ctx context.Context, userID, frequency, channel string, channelID *primitive.ObjectID, slot time.Time) error {
+	digests := database.GetDigestsCollection()
+	key := bson.M{"userId": userID, "frequency": frequency, "channel": channel, "channelId": channelID}
+
+	periodStart := digestPeriodStart(slot, frequency)
+	var previous models.Digest
+	err := digests.FindOne(ctx, key, options.FindOne().SetSort(bson.D{{Key: "scheduledFor", Value: -1}})).Decode(&previous)
+	switch {
+	case err == nil:
+		if !previous.ScheduledFor.Before(slot) {
+			return nil
+		}
+		periodStart = previous.ScheduledFor
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return err
+	}
+
+	now := time.Now()
+	_, err = digests.InsertOne(ctx, models.Digest{
+		ID:           primitive.NewObjectID(),
+		UserID:       userID,
+		Frequency:    frequency,
+		Channel:      channel,
+		ChannelID:    channelID,
+		ScheduledFor: slot,
+		PeriodStart:  periodStart,
+		Status:       StatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// holdForDigest adds an alert that arrived in quiet hours to the summary
+// sent when the window opens, creating the summary for its first alert
+func holdForDigest(ctx context.Context, alert models.Alert, m models.Monitor, channel string, opens time.Time) error {
+	now := time.Now()
+	for _, channelID := range digestChannelIDs(ctx, m.UserID, channel) {
+		_, err := database.GetDigestsCollection().UpdateOne(ctx,
+			bson.M{
+				"userId":       m.UserID,
+				"frequency":    DigestQuiet,
+				"channel":      channel,
+				"channelId":    channelID,
+				"scheduledFor": opens,
+				"status":       StatusDeferred,
+			},
+			bson.M{
+				"$addToSet": bson.M{"alertIds": alert.ID},
+				"$set":      bson.M{"updatedAt": now},
+				"$setOnInsert": bson.M{
+					"_id":           primitive.NewObjectID(),
+					"periodStart":   now,
+					"nextAttemptAt": opens,
+					"failures":      0,
+					"alertCount":    0,
+					"createdAt":     now,
+				},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDigest claims a digest, collects its alerts and sends it
+func sendDigest(id primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	digests := database.GetDigestsCollection()
+
+	now := time.Now()
+	var digest models.Digest
+	err := digests.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "$or": claimable(now)},
+		bson.M{"$set": bson.M{"status": StatusSending, "claimedAt": now, "updatedAt": now}},
+	).Decode(&digest)
+	if err != nil {
+		return
+	}
+
+	d, err := buildDigest(ctx, digest)
+	var update bson.M
+	switch {
+	case err == nil && len(d.Monitors) == 0:
+		update = bson.M{
+			"$set":   bson.M{"status": StatusEmpty, "updatedAt": time.Now()},
+			"$unset": bson.M{"claimedAt": ""},
+		}
+	default:
+		if err == nil {
+			err = deliverDigest(ctx, digest, d)
+		}
+		update = outcomeUpdate(digest.Failures, err)
+		update["$set"].(bson.M)["alertCount"] = len(d.alerts())
+		if err != nil {
+			update["$set"].(bson.M)["error"] = err.Error()
+			log.Printf("[notifier] %s %s digest %s for user %s failed (%s): %v", digest.Frequency, digest.Channel, id.Hex(), digest.UserID, retryNote(update), err)
+		} else {
+			update["$unset"].(bson.M)["error"] = ""
+			log.Printf("[notifier] Sent %s %s digest with %d alerts to user %s", digest.Frequency, digest.Channel, len(d.alerts()), digest.UserID)
+		}
+	}
+
+	if _, err := digests.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		log.Printf("[notifier] Failed to record digest %s: %v", id.Hex(), err)
+	}
+}
+
+// buildDigest collects the alerts of the monitors a digest covers. Alerts
+// count by their last change, so collapsed alerts that changed again
+// during the period are included.
+func buildDigest(ctx context.Context, digest models.Digest) (DigestNotification, error) {
+	settings, err := UserSettings(ctx, digest.UserID)
+	if err != nil {
+		return DigestNotification{}, fmt.Errorf("load settings: %w", err)
+	}
+
+	d := DigestNotification{
+		ID:          digest.ID.Hex(),
+		UserID:      digest.UserID,
+		Frequency:   digest.Frequency,
+		PeriodStart: digest.PeriodStart,
+		PeriodEnd:   digest.ScheduledFor,
+		Location:    settingsLocation(settings),
+	}
+
+	var alerts []models.Alert
+	var byID map[primitive.ObjectID]models.Monitor
+	if digest.Frequency == DigestQuiet {
+		alerts, byID, err = heldAlerts(ctx, digest)
+	} else {
+		alerts, byID, err = scheduledDigestAlerts(ctx, digest, settings)
+	}
+	if err != nil {
+		return d, err
+	}
+
+	index := map[primitive.ObjectID]int{}
+	for _, alert := range alerts {
+		i, ok := index[alert.MonitorID]
+		if !ok {
+			i = len(d.Monitors)
+			index[alert.MonitorID] = i
+			d.Monitors = append(d.Monitors, DigestMonitor{Monitor: byID[alert.MonitorID]})
+		}
+		d.Monitors[i].Alerts = append(d.Monitors[i].Alerts, alert)
+		d.Monitors[i].Changes += max(alert.Count, 1)
+	}
+
+	// Busiest monitors first
+	sort.SliceStable(d.Monitors, func(i, j int) bool {
+		return d.Monitors[i].Changes > d.Monitors[j].Changes
+	})
+	return d, nil
+}
+
+// scheduledDigestAlerts returns the alerts of a daily or weekly digest:
+// those of its channel's digested monitors that changed in its period
+func scheduledDigestAlerts(ctx context.Context, digest models.Digest, settings models.NotificationSettings) ([]models.Alert, map[primitive.ObjectID]models.Monitor, error) {
+	monitors, err := digestMonitors(ctx, digest.UserID, settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load monitors: %w", err)
+	}
+	byID := map[primitive.ObjectID]models.Monitor{}
+	var ids []primitive.ObjectID
+	for _, m := range monitors[digest.Frequency] {
+		if m.NotificationMethod == digest.Channel {
+			byID[m.ID] = m
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, byID, nil
+	}
+
+	window := bson.M{"$gt": digest.PeriodStart, "$lte": digest.ScheduledFor}
+	alerts, err := findDigestAlerts(ctx, bson.M{
+		"userId":    digest.UserID,
+		"monitorId": bson.M{"$in": ids},
+		"archived":  bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"lastChangeAt": window},
+			bson.M{"lastChangeAt": bson.M{"$exists": false}, "receivedAt": window},
+		},
+	})
+	return alerts, byID, err
+}
+
+// heldAlerts returns the alerts held for a quiet-hours summary that still
+// exist, with their monitors
+func heldAlerts(ctx context.Context, digest models.Digest) ([]models.Alert, map[primitive.ObjectID]models.Monitor, error) {
+	alerts, err := findDigestAlerts(ctx, bson.M{
+		"_id":      bson.M{"$in": digest.AlertIDs},
+		"userId":   digest.UserID,
+		"archived": bson.M{"$ne": true},
+	})
+	if err != nil || len(alerts) == 0 {
+		return nil, nil, err
+	}
+
+	var ids []primitive.ObjectID
+	for _, alert := range alerts {
+		ids = append(ids, alert.MonitorID)
+	}
+	cursor, err := database.GetMonitorsCollection().Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("load monitors: %w", err)
+	}
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		return nil, nil, fmt.Errorf("load monitors: %w", err)
+	}
+
+	byID := map[primitive.ObjectID]models.Monitor{}
+	for _, m := range monitors {
+		byID[m.ID] = m
+	}
+	return alerts, byID, nil
+}
+
+// findDigestAlerts loads up to maxDigestAlerts alerts, newest first
+func findDigestAlerts(ctx context.Context, filter bson.M) ([]models.Alert, error) {
+	cursor, err := database.GetAlertsCollection().Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "receivedAt", Value: -1}}).SetLimit(maxDigestAlerts),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load alerts: %w", err)
+	}
+	var alerts []models.Alert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, fmt.Errorf("load alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// deliverDigest hands a digest to its channel's notifier
+func deliverDigest(ctx context.Context, digest models.Digest, d DigestNotification) error {
+	n, ok := lookup(digest.Channel)
+	if !ok {
+		return ErrNoNotifier
+	}
+
+	if digest.ChannelID == nil {
+		sender, ok := n.(digestSender)
+		if !ok {
+			return fmt.Errorf("no %s channel configured", digest.Channel)
+		}
+		return sender.SendDigest(ctx, d)
+	}
+
+	var channel models.Channel
+	if err := database.GetChannelsCollection().FindOne(ctx, bson.M{"_id": *digest.ChannelID, "userId": digest.UserID}).Decode(&channel); err != nil {
+		return fmt.Errorf("load channel: %w", err)
+	}
+	if !channel.Enabled {
+		return errChannelDisabled
+	}
+	sender, ok := n.(digestChannelSender)
+	if !ok {
+		return ErrNoNotifier
+	}
+	return sender.sendDigestTo(ctx, channel, d)
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"justping/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestLastDigestSlot(t *testing.T) {
+	utc := models.NotificationSettings{Timezone: "UTC", DigestTime: "09:00", DigestWeekday: int(time.Monday)}
+	berlin := models.NotificationSettings{Timezone: "Europe/Berlin", DigestTime: "08:00", DigestWeekday: int(time.Monday)}
+	date := func(day, hour, min int) time.Time {
+		return time.Date(2026, time.March, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		settings  models.NotificationSettings
+		frequency string
+		want      time.Time
+	}{
+		{"daily after the time", date(4, 10, 0), utc, DigestDaily, date(4, 9, 0)},
+		{"daily at the time", date(4, 9, 0), utc, DigestDaily, date(4, 9, 0)},
+		{"daily before the time", date(4, 8, 59), utc, DigestDaily, date(3, 9, 0)},
+		{"weekly mid-week", date(4, 10, 0), utc, DigestWeekly, date(2, 9, 0)},
+		{"weekly on the day after the time", date(9, 9, 30), utc, DigestWeekly, date(9, 9, 0)},
+		{"weekly on the day before the time", date(9, 8, 0), utc, DigestWeekly, date(2, 9, 0)},
+		{"user timezone", date(4, 7, 30), berlin, DigestDaily, date(4, 7, 0)},
+		{"across daylight saving", date(29, 6, 30), berlin, DigestDaily, date(29, 6, 0)},
+		{"invalid time falls back to 09:00", date(4, 10, 0), models.NotificationSettings{Timezone: "UTC", DigestTime: "25:00"}, DigestDaily, date(4, 9, 0)},
+		{"invalid timezone falls back to UTC", date(4, 10, 0), models.NotificationSettings{Timezone: "Mars/Olympus", DigestTime: "09:00"}, DigestDaily, date(4, 9, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastDigestSlot(tt.now, tt.settings, tt.frequency); !got.Equal(tt.want) {
+				t.Errorf("lastDigestSlot(%v) = %v, want %v", tt.now, got.UTC(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Discord limits embed titles to 256 and descriptions to 4096 characters
+	discordMaxTitle       = 256
+	discordMaxDescription = 4096
+	// discordColor is the embed accent, JustPing blue
+	discordColor = 0x3B82F6
+)
+
+// renderDiscord formats an alert as a Discord embed
+func renderDiscord(n Notification) ([]byte, error) {
+	lines, more := excerpt(n.Alert, maxExcerptLines)
+
+	description := summary(n.Alert)
+	if len(lines) > 0 {
+		snippet := strings.Join(lines, "\n")
+		if more > 0 {
+			snippet += fmt.Sprintf("\n… and %d more changed lines", more)
+		}
+		// Leave room for the code fence around the snippet
+		room := discordMaxDescription - len(description) - len("\n```diff\n\n```")
+		description += "\n```diff\n" + truncate(strings.ReplaceAll(snippet, "```", "'''"), room) + "\n```"
+	}
+	// Cut the text, not the link after it
+	link := fmt.Sprintf("\n[View alert](%s)", alertLink(n.Alert))
+	description = truncate(description, discordMaxDescription-len(link)) + link
+
+	embed := map[string]any{
+		"title":       truncate(headline(n), discordMaxTitle),
+		"url":         pageURL(n),
+		"description": description,
+		"color":       discordColor,
+		"timestamp":   n.Alert.ReceivedAt.UTC().Format(time.RFC3339),
+		"footer":      map[string]any{"text": "JustPing"},
+	}
+
+	return json.Marshal(map[string]any{
+		"username": "JustPing",
+		"embeds":   []map[string]any{embed},
+	})
+}
+
+// renderDiscordDigest formats a digest as a Discord embed
+func renderDiscordDigest(d DigestNotification) ([]byte, error) {
+	var sb strings.Builder
+	for i, m := range d.Monitors {
+		if i == maxDigestMonitors {
+			fmt.Fprintf(&sb, "… and %s more\n", countOf(len(d.Monitors)-i, "monitor"))
+			break
+		}
+		n := m.latest()
+		fmt.Fprintf(&sb, "**[%s](%s)** · %s\n%s\n\n", monitorName(n), alertLink(n.Alert), countOf(m.Changes, "change"), summary(n.Alert))
+	}
+	link := fmt.Sprintf("[View alerts](%s)", alertsLink())
+
+	embed := map[string]any{
+		"title":       truncate(digestTitle(d), discordMaxTitle),
+		"url":         alertsLink(),
+		"description": truncate(sb.String(), discordMaxDescription-len(link)) + link,
+		"color":       discordColor,
+		"timestamp":   d.PeriodEnd.UTC().Format(time.RFC3339),
+		"footer":      map[string]any{"text": "JustPing · " + digestPeriod(d)},
+	}
+
+	return json.Marshal(map[string]any{
+		"username": "JustPing",
+		"embeds":   []map[string]any{embed},
+	})
+}
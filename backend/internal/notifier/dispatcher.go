@@ -0,0 +1,457 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Delivery statuses
+const (
+	StatusDeferred = "deferred" // Held for quiet hours until nextAttemptAt
+	StatusPending  = "pending"
+	StatusSending  = "sending"
+	StatusSent     = "sent"
+	StatusFailed   = "failed" // Waiting for a retry at nextAttemptAt
+	StatusDead     = "dead"   // Out of attempts, in the dead-letter list
+)
+
+const (
+	// queueSize bounds deliveries waiting for a worker; overflow stays
+	// pending in Mongo until the next sweep
+	queueSize = 256
+	// workers is how many deliveries are sent at once
+	workers = 4
+	// sendTimeout bounds a single attempt, including database writes
+	sendTimeout = 30 * time.Second
+	// sweepInterval is how often pending, due and stalled deliveries are
+	// queued again
+	sweepInterval = 30 * time.Second
+	// claimTimeout is how long a delivery may stay "sending" before it is
+	// assumed lost, e.g. because the process stopped mid-send
+	claimTimeout = 5 * time.Minute
+	// maxAttempts is how often a delivery is tried before it moves to the
+	// dead-letter list
+	maxAttempts = 6
+	// retryBaseDelay is the wait after the first failure; it doubles with
+	// every further failure
+	retryBaseDelay = time.Minute
+)
+
+// ErrNotRetryable is returned by Retry for deliveries that did not fail
+var ErrNotRetryable = errors.New("delivery has not failed")
+
+var (
+	queue  chan primitive.ObjectID
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+)
+
+// Start registers the configured channels and launches the dispatch
+// workers, the sweep that picks up deliveries left over from a previous
+// run and the digest scheduler
+func Start() {
+	registerFromEnv()
+
+	queue = make(chan primitive.ObjectID, queueSize)
+	stopCh = make(chan struct{})
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go worker()
+	}
+
+	// Listen for chats linking themselves to an account
+	if telegram != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-stopCh
+			cancel()
+		}()
+		go func() {
+			defer wg.Done()
+			telegram.poll(ctx)
+		}()
+	}
+
+	// Send digests as they come due
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(digestInterval)
+		defer ticker.Stop()
+
+		runDigests()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				runDigests()
+			}
+		}
+	}()
+
+	// Escalate alerts nobody checked
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(escalationInterval)
+		defer ticker.Stop()
+
+		runEscalations()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				runEscalations()
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		sweep()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+	}()
+
+	log.Printf("[notifier] Started with %d workers", workers)
+}
+
+// Stop waits for in-flight deliveries to finish. Queued deliveries stay
+// pending and are sent after the next start.
+func Stop() {
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	wg.Wait()
+	stopCh = nil
+}
+
+// Dispatch records a pending delivery of alert for every channel of its
+// monitor and queues them for sending. During the quiet hours of the
+// monitor's schedule the deliveries wait for the next window, or the alert
+// joins a summary sent when it opens.
+func Dispatch(ctx context.Context, alert models.Alert, m models.Monitor) {
+	settings, err := UserSettings(ctx, m.UserID)
+	if err != nil {
+		log.Printf("[notifier] Failed to load settings of user %s, using defaults: %v", m.UserID, err)
+	}
+
+	if frequency := digestFrequency(m, settings); frequency == DigestDaily || frequency == DigestWeekly {
+		// Sent with the next digest instead
+		return
+	}
+
+	now := time.Now()
+	schedule, loc := monitorSchedule(m, settings)
+	opens := nextAllowed(schedule, loc, now)
+	quiet := opens.After(now)
+
+	for _, channel := range channelsFor(m) {
+		if quiet && schedule.QuietAction == QuietDigest {
+			err := holdForDigest(ctx, alert, m, channel, opens)
+			if err == nil {
+				continue
+			}
+			log.Printf("[notifier] Failed to hold alert %s for the quiet-hours summary, deferring it: %v", alert.ID.Hex(), err)
+		}
+
+		for _, delivery := range newDeliveries(ctx, alert, m, channel) {
+			if quiet {
+				delivery.Status = StatusDeferred
+				delivery.NextAttemptAt = &opens
+			}
+			if _, err := database.GetDeliveriesCollection().InsertOne(ctx, delivery); err != nil {
+				log.Printf("[notifier] Failed to record %s delivery for alert %s: %v", channel, alert.ID.Hex(), err)
+				continue
+			}
+			if !quiet {
+				enqueue(delivery.ID)
+			}
+		}
+	}
+}
+
+// newDeliveries builds the deliveries of alert over one channel type. For
+// types users configure themselves there is one per enabled user channel,
+// so a failing endpoint is retried without repeating the others.
+func newDeliveries(ctx context.Context, alert models.Alert, m models.Monitor, channelType string) []models.Delivery {
+	now := time.Now()
+	newDelivery := func(channelID *primitive.ObjectID) models.Delivery {
+		return models.Delivery{
+			ID:        primitive.NewObjectID(),
+			AlertID:   alert.ID,
+			MonitorID: m.ID,
+			UserID:    m.UserID,
+			Channel:   channelType,
+			ChannelID: channelID,
+			Status:    StatusPending,
+			Attempts:  []models.DeliveryAttempt{},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	n, ok := lookup(channelType)
+	if _, perChannel := n.(channelSender); !ok || !perChannel {
+		return []models.Delivery{newDelivery(nil)}
+	}
+
+	channels, err := userChannels(ctx, m.UserID, channelType)
+	if err != nil {
+		log.Printf("[notifier] Failed to load %s channels for user %s: %v", channelType, m.UserID, err)
+	}
+	if len(channels) == 0 {
+		// Sending reports the missing channel on the delivery
+		return []models.Delivery{newDelivery(nil)}
+	}
+
+	deliveries := make([]models.Delivery, 0, len(channels))
+	for _, channel := range channels {
+		channelID := channel.ID
+		deliveries = append(deliveries, newDelivery(&channelID))
+	}
+	return deliveries
+}
+
+// Retry sends a failed or dead delivery again right away, with a fresh
+// set of attempts before it returns to the dead-letter list
+func Retry(ctx context.Context, id primitive.ObjectID, userID string) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := database.GetDeliveriesCollection().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "userId": userID, "status": bson.M{"$in": bson.A{StatusFailed, StatusDead}}},
+		bson.M{
+			"$set":   bson.M{"status": StatusPending, "failures": 0, "updatedAt": time.Now()},
+			"$unset": bson.M{"nextAttemptAt": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotRetryable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	enqueue(delivery.ID)
+	return &delivery, nil
+}
+
+// enqueue hands a delivery to the workers without blocking
+func enqueue(id primitive.ObjectID) {
+	if queue == nil {
+		return
+	}
+	select {
+	case queue <- id:
+	default:
+		log.Printf("[notifier] Queue full, delivery %s waits for the next sweep", id.Hex())
+	}
+}
+
+// claimable matches deliveries a worker may pick up: new ones, deferred
+// and failed ones whose time has come and ones whose worker went away
+func claimable(now time.Time) bson.A {
+	return bson.A{
+		bson.M{"status": StatusPending},
+		bson.M{"status": StatusDeferred, "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{"status": StatusFailed, "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{"status": StatusSending, "claimedAt": bson.M{"$lt": now.Add(-claimTimeout)}},
+	}
+}
+
+// retryDelay is the backoff after a delivery failed failures times in a row
+func retryDelay(failures int) time.Duration {
+	return retryBaseDelay << (failures - 1)
+}
+
+// outcomeUpdate builds the update recording an attempt's result: sent,
+// failed with a retry scheduled, or dead once out of attempts. failures
+// counts the failed attempts before this one.
+func outcomeUpdate(failures int, sendErr error) bson.M {
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": now},
+		"$unset": bson.M{"claimedAt": ""},
+	}
+	if sendErr == nil {
+		update["$set"].(bson.M)["status"] = StatusSent
+		update["$set"].(bson.M)["sentAt"] = now
+		update["$unset"].(bson.M)["nextAttemptAt"] = ""
+		return update
+	}
+
+	failures++
+	update["$set"].(bson.M)["failures"] = failures
+	if failures >= maxAttempts || permanent(sendErr) {
+		update["$set"].(bson.M)["status"] = StatusDead
+		update["$unset"].(bson.M)["nextAttemptAt"] = ""
+	} else {
+		update["$set"].(bson.M)["status"] = StatusFailed
+		update["$set"].(bson.M)["nextAttemptAt"] = now.Add(retryDelay(failures))
+	}
+	return update
+}
+
+// retryNote describes what happens next after a failed attempt, for logs
+func retryNote(update bson.M) string {
+	next, ok := update["$set"].(bson.M)["nextAttemptAt"].(time.Time)
+	if !ok {
+		return fmt.Sprintf("giving up after %d attempts", update["$set"].(bson.M)["failures"])
+	}
+	return "retrying at " + next.Format(time.RFC3339)
+}
+
+// sweep queues deliveries that are pending, due for a retry or whose
+// worker went away
+func sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := database.GetDeliveriesCollection().Find(ctx,
+		bson.M{"$or": claimable(time.Now())},
+		options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(queueSize),
+	)
+	if err != nil {
+		log.Printf("[notifier] Failed to query pending deliveries: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var pending []models.Delivery
+	if err := cursor.All(ctx, &pending); err != nil {
+		log.Printf("[notifier] Failed to decode pending deliveries: %v", err)
+		return
+	}
+	for _, d := range pending {
+		enqueue(d.ID)
+	}
+}
+
+func worker() {
+	defer wg.Done()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case id := <-queue:
+			deliver(id)
+		}
+	}
+}
+
+// deliver claims a delivery, sends it and records the attempt
+func deliver(id primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	deliveries := database.GetDeliveriesCollection()
+
+	// Claim the delivery so a concurrent sweep does not send it twice
+	now := time.Now()
+	var delivery models.Delivery
+	err := deliveries.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "$or": claimable(now)},
+		bson.M{"$set": bson.M{"status": StatusSending, "claimedAt": now, "updatedAt": now}},
+	).Decode(&delivery)
+	if err != nil {
+		return
+	}
+
+	start := time.Now()
+	resp, sendErr := send(ctx, delivery)
+	attempt := models.DeliveryAttempt{At: start, DurationMs: time.Since(start).Milliseconds()}
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
+		attempt.ResponseBody = resp.Body
+	}
+
+	update := outcomeUpdate(delivery.Failures, sendErr)
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		log.Printf("[notifier] %s delivery %s for alert %s failed (%s): %v", delivery.Channel, id.Hex(), delivery.AlertID.Hex(), retryNote(update), sendErr)
+	}
+	update["$push"] = bson.M{"attempts": attempt}
+
+	if _, err := deliveries.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		log.Printf("[notifier] Failed to record attempt for delivery %s: %v", id.Hex(), err)
+	}
+}
+
+// send loads the alert and monitor behind a delivery and hands them to its
+// notifier. Webhook endpoints also return their response for the log.
+func send(ctx context.Context, delivery models.Delivery) (*webhookResponse, error) {
+	n, ok := lookup(delivery.Channel)
+	if !ok {
+		return nil, ErrNoNotifier
+	}
+
+	notification := Notification{Escalation: delivery.Escalation}
+	if err := database.GetAlertsCollection().FindOne(ctx, bson.M{"_id": delivery.AlertID}).Decode(&notification.Alert); err != nil {
+		return nil, fmt.Errorf("load alert: %w", err)
+	}
+	if err := database.GetMonitorsCollection().FindOne(ctx, bson.M{"_id": delivery.MonitorID}).Decode(&notification.Monitor); err != nil {
+		return nil, fmt.Errorf("load monitor: %w", err)
+	}
+
+	if delivery.ChannelID == nil {
+		return nil, n.Send(ctx, notification)
+	}
+
+	var channel models.Channel
+	if err := database.GetChannelsCollection().FindOne(ctx, bson.M{"_id": *delivery.ChannelID, "userId": delivery.UserID}).Decode(&channel); err != nil {
+		return nil, fmt.Errorf("load channel: %w", err)
+	}
+	if !channel.Enabled {
+		return nil, errChannelDisabled
+	}
+
+	if webhook, ok := n.(*webhookNotifier); ok {
+		return webhook.post(ctx, channel, notification, delivery.ID.Hex())
+	}
+	sender, ok := n.(channelSender)
+	if !ok {
+		return nil, ErrNoNotifier
+	}
+	return nil, sender.sendTo(ctx, channel, notification)
+}
+
+// errChannelDisabled is recorded for deliveries to a channel the user
+// turned off after the alert was dispatched
+var errChannelDisabled = errors.New("channel is disabled")
+
+// permanent reports whether a failed delivery cannot succeed on retry,
+// e.g. because its alert or channel was deleted
+func permanent(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.permanent()
+	}
+	return errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, ErrNoNotifier) || errors.Is(err, errChannelDisabled)
+}
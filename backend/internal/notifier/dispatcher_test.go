@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{5, 16 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.failures); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestOutcomeUpdate(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantStatus   string
+		wantFailures int           // 0 when the update leaves failures alone
+		wantRetryIn  time.Duration // 0 when no retry is scheduled
+	}{
+		{"sent", 0, nil, StatusSent, 0, 0},
+		{"sent after failures", 3, nil, StatusSent, 0, 0},
+		{"first failure", 0, errors.New("connection refused"), StatusFailed, 1, time.Minute},
+		{"third failure", 2, &statusError{StatusCode: 503}, StatusFailed, 3, 4 * time.Minute},
+		{"rate limited", 1, &statusError{StatusCode: 429}, StatusFailed, 2, 2 * time.Minute},
+		{"out of attempts", maxAttempts - 1, errors.New("timeout"), StatusDead, maxAttempts, 0},
+		{"rejected by endpoint", 0, fmt.Errorf("send: %w", &statusError{StatusCode: 410}), StatusDead, 1, 0},
+		{"alert deleted", 0, mongo.ErrNoDocuments, StatusDead, 1, 0},
+		{"channel disabled", 0, errChannelDisabled, StatusDead, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			update := outcomeUpdate(tt.failures, tt.err)
+			set := update["$set"].(bson.M)
+			unset := update["$unset"].(bson.M)
+
+			if set["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", set["status"], tt.wantStatus)
+			}
+			if _, ok := unset["claimedAt"]; !ok {
+				t.Error("claimedAt is not unset")
+			}
+
+			if tt.wantFailures == 0 {
+				if _, ok := set["failures"]; ok {
+					t.Errorf("failures = %v, want it left alone", set["failures"])
+				}
+			} else if set["failures"] != tt.wantFailures {
+				t.Errorf("failures = %v, want %d", set["failures"], tt.wantFailures)
+			}
+
+			if _, ok := set["sentAt"]; ok != (tt.wantStatus == StatusSent) {
+				t.Errorf("sentAt set = %v, want %v", ok, tt.wantStatus == StatusSent)
+			}
+
+			next, retry := set["nextAttemptAt"].(time.Time)
+			if tt.wantRetryIn == 0 {
+				if retry {
+					t.Errorf("nextAttemptAt = %v, want no retry", next)
+				}
+				if _, ok := unset["nextAttemptAt"]; !ok {
+					t.Error("nextAttemptAt is not unset")
+				}
+				return
+			}
+			if !retry {
+				t.Fatalf("no retry scheduled, want one in %v", tt.wantRetryIn)
+			}
+			if in := next.Sub(before); in < tt.wantRetryIn || in > tt.wantRetryIn+time.Second {
+				t.Errorf("retry in %v, want %v", in, tt.wantRetryIn)
+			}
+		})
+	}
+}
@@ -0,0 +1,462 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"justping/backend/internal/database"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChannelEmail is the notification method for email
+const ChannelEmail = "email"
+
+// SMTP connection security modes
+const (
+	SMTPStartTLS = "starttls" // Plain connection upgraded with STARTTLS
+	SMTPTLS      = "tls"      // Implicit TLS, usually port 465
+	SMTPNone     = "none"     // No encryption, for local stand-ins only
+)
+
+// EmailNotifier sends alerts to the monitor owner's address over SMTP
+type EmailNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string // e.g. "JustPing <alerts@example.com>"
+	TLSMode  string // starttls, tls or none
+}
+
+// NewEmailNotifierFromEnv configures email from SMTP_* variables. It
+// returns nil when SMTP_HOST is not set.
+func NewEmailNotifierFromEnv() (*EmailNotifier, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	tlsMode := strings.ToLower(os.Getenv("SMTP_TLS"))
+	if tlsMode == "" {
+		tlsMode = SMTPStartTLS
+		if port == "465" {
+			tlsMode = SMTPTLS
+		}
+	}
+	if tlsMode != SMTPStartTLS && tlsMode != SMTPTLS && tlsMode != SMTPNone {
+		return nil, fmt.Errorf("invalid SMTP_TLS %q: use starttls, tls or none", tlsMode)
+	}
+
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "JustPing <alerts@justping.local>"
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+
+	return &EmailNotifier{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+		TLSMode:  tlsMode,
+	}, nil
+}
+
+func (e *EmailNotifier) Name() string {
+	return ChannelEmail
+}
+
+// Send mails the alert to the address of the monitor's owner
+func (e *EmailNotifier) Send(ctx context.Context, n Notification) error {
+	to, err := userEmail(ctx, n.Monitor.UserID)
+	if err != nil {
+		return fmt.Errorf("find recipient: %w", err)
+	}
+
+	msg, err := e.buildMessage(to, n, time.Now())
+	if err != nil {
+		return err
+	}
+	return e.sendMail(ctx, to, msg)
+}
+
+// SendDigest mails a digest to the user it belongs to
+func (e *EmailNotifier) SendDigest(ctx context.Context, d DigestNotification) error {
+	to, err := userEmail(ctx, d.UserID)
+	if err != nil {
+		return fmt.Errorf("find recipient: %w", err)
+	}
+
+	msg, err := e.buildDigestMessage(to, d, time.Now())
+	if err != nil {
+		return err
+	}
+	return e.sendMail(ctx, to, msg)
+}
+
+// userEmail looks up a user's address in the auth service's collection
+func userEmail(ctx context.Context, userID string) (string, error) {
+	var id interface{} = userID
+	if oid, err := primitive.ObjectIDFromHex(userID); err == nil {
+		id = oid
+	}
+
+	var user struct {
+		Email string `bson:"email"`
+	}
+	if err := database.GetUsersCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+		return "", err
+	}
+	if user.Email == "" {
+		return "", errors.New("user has no email address")
+	}
+	return user.Email, nil
+}
+
+// emailData is what the templates render
+type emailData struct {
+	Headline    string
+	Escalated   bool // Sent again because nobody checked the alert
+	MonitorName string
+	URL         string
+	Summary     string
+	Excerpt     []string
+	More        int // Changed lines left out of Excerpt
+	Link        string
+	ReceivedAt  string
+}
+
+func emailTemplateData(n Notification) emailData {
+	d := emailData{
+		Headline:    headline(n),
+		Escalated:   n.Escalation > 0,
+		MonitorName: monitorName(n),
+		URL:         pageURL(n),
+		Summary:     summary(n.Alert),
+		Link:        alertLink(n.Alert),
+		ReceivedAt:  n.Alert.ReceivedAt.UTC().Format("2 Jan 2006 15:04 UTC"),
+	}
+
+	d.Excerpt, d.More = excerpt(n.Alert, maxExcerptLines)
+
+	return d
+}
+
+var emailSubjectTemplate = template.Must(template.New("subject").Parse(
+	`[JustPing] {{.Headline}}`))
+
+var emailTextTemplate = template.Must(template.New("text").Parse(`{{if .Escalated}}Nobody has checked this alert yet.
+
+{{end}}A change was detected on {{.MonitorName}}.
+
+Page:     {{.URL}}
+Detected: {{.ReceivedAt}}
+Summary:  {{.Summary}}
+{{if .Excerpt}}
+{{range .Excerpt}}  {{.}}
+{{end}}{{if .More}}  ... and {{.More}} more changed lines
+{{end}}{{end}}
+View the alert: {{.Link}}
+`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #111; line-height: 1.5;">
+  <h2 style="margin: 0 0 12px;">{{.Headline}}</h2>
+  {{- if .Escalated}}
+  <p style="margin: 0 0 12px; color: #b42318;">Nobody has checked this alert yet.</p>
+  {{- end}}
+  <p style="margin: 0 0 4px;"><a href="{{.URL}}">{{.URL}}</a></p>
+  <p style="margin: 0 0 16px; color: #555;">{{.ReceivedAt}} &middot; {{.Summary}}</p>
+  {{- if .Excerpt}}
+  <pre style="background: #f6f8fa; border-radius: 6px; padding: 12px; font-size: 13px; white-space: pre-wrap;">
+{{- range .Excerpt}}
+{{.}}
+{{- end}}</pre>
+  {{- if .More}}
+  <p style="color: #555;">&hellip; and {{.More}} more changed lines</p>
+  {{- end}}
+  {{- end}}
+  <p><a href="{{.Link}}" style="display: inline-block; background: #111; color: #fff; padding: 8px 16px; border-radius: 6px; text-decoration: none;">View alert</a></p>
+</body>
+</html>
+`))
+
+// digestEmailData is what the digest templates render
+type digestEmailData struct {
+	Title    string
+	Period   string
+	Link     string
+	Monitors []digestEmailMonitor
+}
+
+type digestEmailMonitor struct {
+	Name    string
+	URL     string
+	Changes string // e.g. "3 changes"
+	Alerts  []digestEmailAlert
+	More    int // Alerts left out
+}
+
+type digestEmailAlert struct {
+	Summary string
+	At      string
+	Link    string
+}
+
+// maxDigestEmailAlerts caps the alerts listed per monitor in digest emails
+const maxDigestEmailAlerts = 5
+
+func digestEmailTemplateData(d DigestNotification) digestEmailData {
+	data := digestEmailData{
+		Title:  digestTitle(d),
+		Period: digestPeriod(d),
+		Link:   alertsLink(),
+	}
+	for _, m := range d.Monitors {
+		n := m.latest()
+		entry := digestEmailMonitor{
+			Name:    monitorName(n),
+			URL:     pageURL(n),
+			Changes: countOf(m.Changes, "change"),
+			More:    max(len(m.Alerts)-maxDigestEmailAlerts, 0),
+		}
+		for i, alert := range m.Alerts {
+			if i == maxDigestEmailAlerts {
+				break
+			}
+			at := alert.ReceivedAt
+			if alert.LastChangeAt != nil {
+				at = *alert.LastChangeAt
+			}
+			entry.Alerts = append(entry.Alerts, digestEmailAlert{
+				Summary: summary(alert),
+				At:      at.In(d.Location).Format("Mon 2 Jan 15:04"),
+				Link:    alertLink(alert),
+			})
+		}
+		data.Monitors = append(data.Monitors, entry)
+	}
+	return data
+}
+
+var digestSubjectTemplate = template.Must(template.New("digest-subject").Parse(
+	`[JustPing] {{.Title}}`))
+
+var digestTextTemplate = template.Must(template.New("digest-text").Parse(`{{.Title}}
+{{.Period}}
+{{range .Monitors}}
+{{.Name}} ({{.Changes}})
+{{.URL}}
+{{range .Alerts}}  {{.At}}  {{.Summary}}
+    {{.Link}}
+{{end}}{{if .More}}  ... and {{.More}} more alerts
+{{end}}{{end}}
+View all alerts: {{.Link}}
+`))
+
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest-html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #111; line-height: 1.5;">
+  <h2 style="margin: 0 0 4px;">{{.Title}}</h2>
+  <p style="margin: 0 0 16px; color: #555;">{{.Period}}</p>
+  {{- range .Monitors}}
+  <h3 style="margin: 16px 0 4px;">{{.Name}} <span style="font-weight: normal; color: #555;">&middot; {{.Changes}}</span></h3>
+  <p style="margin: 0 0 8px;"><a href="{{.URL}}">{{.URL}}</a></p>
+  <ul style="margin: 0; padding-left: 20px;">
+    {{- range .Alerts}}
+    <li><span style="color: #555;">{{.At}}</span> &middot; <a href="{{.Link}}">{{.Summary}}</a></li>
+    {{- end}}
+    {{- if .More}}
+    <li style="color: #555;">&hellip; and {{.More}} more alerts</li>
+    {{- end}}
+  </ul>
+  {{- end}}
+  <p style="margin-top: 24px;"><a href="{{.Link}}" style="display: inline-block; background: #111; color: #fff; padding: 8px 16px; border-radius: 6px; text-decoration: none;">View all alerts</a></p>
+</body>
+</html>
+`))
+
+// emailTemplates renders one kind of email
+type emailTemplates struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+var (
+	alertEmail  = emailTemplates{emailSubjectTemplate, emailTextTemplate, emailHTMLTemplate}
+	digestEmail = emailTemplates{digestSubjectTemplate, digestTextTemplate, digestHTMLTemplate}
+)
+
+// buildMessage renders a multipart/alternative message with plain-text
+// and HTML versions of the alert
+func (e *EmailNotifier) buildMessage(to string, n Notification, now time.Time) ([]byte, error) {
+	return e.compose(to, alertEmail, emailTemplateData(n), now)
+}
+
+// buildDigestMessage renders a digest like buildMessage renders an alert
+func (e *EmailNotifier) buildDigestMessage(to string, d DigestNotification, now time.Time) ([]byte, error) {
+	return e.compose(to, digestEmail, digestEmailTemplateData(d), now)
+}
+
+// compose renders data with t into a multipart/alternative message
+func (e *EmailNotifier) compose(to string, t emailTemplates, data any, now time.Time) ([]byte, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("render subject: %w", err)
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("render text body: %w", err)
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("render html body: %w", err)
+	}
+
+	var msg bytes.Buffer
+	parts := multipart.NewWriter(&msg)
+
+	from, _ := mail.ParseAddress(e.From)
+	header := []string{
+		"From: " + from.String(),
+		"To: " + (&mail.Address{Address: to}).String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject.String()),
+		"Date: " + now.Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	msg.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+// messageID returns a unique Message-ID in the sender's domain
+func messageID(from string) string {
+	domain := "justping.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain)
+}
+
+// sendMail delivers msg to one recipient, honouring the TLS mode
+func (e *EmailNotifier) sendMail(ctx context.Context, to string, msg []byte) error {
+	addr := net.JoinHostPort(e.Host, e.Port)
+	tlsConfig := &tls.Config{ServerName: e.Host}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if e.TLSMode == SMTPTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer c.Close()
+
+	if e.TLSMode == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if e.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.From)
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,209 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"justping/backend/internal/models"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receivedMail is what the test SMTP server was sent
+type receivedMail struct {
+	from, to string
+	data     []byte
+}
+
+// startSMTPServer runs a minimal SMTP server for one session on a local
+// port and returns its address and the mail it receives
+func startSMTPServer(t *testing.T) (host, port string, received <-chan receivedMail) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan receivedMail, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		tp := textproto.NewConn(conn)
+		var m receivedMail
+		tp.PrintfLine("220 localhost ESMTP test")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				m.from = angleAddr(line)
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				m.to = angleAddr(line)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				m.data, err = tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				tp.PrintfLine("250 OK")
+				ch <- m
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				return
+			default:
+				tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, ch
+}
+
+// angleAddr returns the address between < and > in an SMTP command
+func angleAddr(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func testEmailNotification() Notification {
+	return Notification{
+		Monitor: models.Monitor{WebsiteName: "Pricing", URL: "https://example.com/pricing"},
+		Alert: models.Alert{
+			ReceivedAt: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+			AlertDetails: models.AlertDetails{
+				Summary: "1 line added",
+				Added:   []string{"Pro plan: €12/month"},
+			},
+		},
+	}
+}
+
+func TestEmailSendMail(t *testing.T) {
+	host, port, received := startSMTPServer(t)
+	e := &EmailNotifier{Host: host, Port: port, From: "JustPing <alerts@example.com>", TLSMode: SMTPNone}
+
+	now := time.Date(2026, 3, 1, 9, 31, 0, 0, time.UTC)
+	msg, err := e.buildMessage("user@example.com", testEmailNotification(), now)
+	if err != nil {
+		t.Fatalf("buildMessage: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.sendMail(ctx, "user@example.com", msg); err != nil {
+		t.Fatalf("sendMail: %v", err)
+	}
+
+	var got receivedMail
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server received no mail")
+	}
+	if got.from != "alerts@example.com" || got.to != "user@example.com" {
+		t.Errorf("envelope = %q -> %q, want alerts@example.com -> user@example.com", got.from, got.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(got.data)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	wantHeaders := map[string]string{
+		"From":         `"JustPing" <alerts@example.com>`,
+		"To":           "<user@example.com>",
+		"Subject":      "[JustPing] Change detected: Pricing",
+		"Date":         "Sun, 01 Mar 2026 09:31:00 +0000",
+		"MIME-Version": "1.0",
+	}
+	for name, want := range wantHeaders {
+		got := parsed.Header.Get(name)
+		if name == "Subject" {
+			got = subject
+		}
+		if got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if id := parsed.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q, want one in example.com", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", parsed.Header.Get("Content-Type"))
+	}
+
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	wantParts := []struct {
+		contentType string
+		contains    []string
+	}{
+		{"text/plain; charset=utf-8", []string{"A change was detected on Pricing.", "+ Pro plan: €12/month", "https://example.com/pricing"}},
+		{"text/html; charset=utf-8", []string{"<h2 style=\"margin: 0 0 12px;\">Change detected: Pricing</h2>", "&#43; Pro plan: €12/month"}},
+	}
+	for i, want := range wantParts {
+		part, err := parts.NextRawPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != want.contentType {
+			t.Errorf("part %d Content-Type = %q, want %q", i, ct, want.contentType)
+		}
+		if cte := part.Header.Get("Content-Transfer-Encoding"); cte != "quoted-printable" {
+			t.Errorf("part %d Content-Transfer-Encoding = %q, want quoted-printable", i, cte)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("part %d: decode body: %v", i, err)
+		}
+		for _, s := range want.contains {
+			if !strings.Contains(string(body), s) {
+				t.Errorf("part %d does not contain %q:\n%s", i, s, body)
+			}
+		}
+	}
+	if _, err := parts.NextRawPart(); err != io.EOF {
+		t.Errorf("message has more than two parts (err %v)", err)
+	}
+}
+
+func TestEmailSendMailRequiresStartTLS(t *testing.T) {
+	host, port, _ := startSMTPServer(t)
+	e := &EmailNotifier{Host: host, Port: port, From: "alerts@example.com", TLSMode: SMTPStartTLS}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := e.sendMail(ctx, "user@example.com", []byte("Subject: test\r\n\r\nbody\r\n"))
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("sendMail without STARTTLS support = %v, want a STARTTLS error", err)
+	}
+}
@@ -0,0 +1,288 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	escalationInterval = time.Minute
+	// escalationGrace is how late a step may still be sent, e.g. after
+	// downtime. Older alerts, such as those from before a monitor got its
+	// policy, are not escalated.
+	escalationGrace    = time.Hour
+	maxEscalationSteps = 10
+	// notifyLookback bounds how long after an alert arrived its first
+	// delivery may have gone out, e.g. when deferred over a weekend of
+	// quiet hours or retried
+	notifyLookback = 7 * 24 * time.Hour
+)
+
+// ValidateEscalationSteps checks the steps of a user's escalation policy
+func ValidateEscalationSteps(ctx context.Context, userID string, steps []models.EscalationStep) error {
+	if len(steps) == 0 || len(steps) > maxEscalationSteps {
+		return fmt.Errorf("a policy needs 1 to %d steps", maxEscalationSteps)
+	}
+
+	for i, step := range steps {
+		if step.AfterMinutes < 1 {
+			return fmt.Errorf("step %d: afterMinutes must be at least 1", i+1)
+		}
+		if i > 0 && step.AfterMinutes <= steps[i-1].AfterMinutes {
+			return fmt.Errorf("step %d: afterMinutes must be later than the step before", i+1)
+		}
+
+		n, ok := lookup(step.Channel)
+		if !ok {
+			return fmt.Errorf("step %d: channel %q is not available", i+1, step.Channel)
+		}
+		if step.ChannelID == nil {
+			continue
+		}
+		if _, perChannel := n.(channelSender); !perChannel {
+			return fmt.Errorf("step %d: %s steps cannot name a channelId", i+1, step.Channel)
+		}
+		count, err := database.GetChannelsCollection().CountDocuments(ctx, bson.M{
+			"_id":    *step.ChannelID,
+			"userId": userID,
+			"type":   step.Channel,
+		})
+		if err != nil {
+			return fmt.Errorf("step %d: load channel: %w", i+1, err)
+		}
+		if count == 0 {
+			return fmt.Errorf("step %d: no %s channel %s", i+1, step.Channel, step.ChannelID.Hex())
+		}
+	}
+	return nil
+}
+
+// stepDue returns when step comes due for an alert first notified at
+// notifiedAt
+func stepDue(step models.EscalationStep, notifiedAt time.Time) time.Time {
+	return notifiedAt.Add(time.Duration(step.AfterMinutes) * time.Minute)
+}
+
+// dueStep returns the index of the step to send for an alert at
+// escalation level (the number of steps already sent), or false when none
+// is due. Only the latest due step is sent; steps missed while the API was
+// down are skipped rather than sent all at once, and a step more than
+// escalationGrace overdue is not sent at all.
+func dueStep(steps []models.EscalationStep, level int, notifiedAt, now time.Time) (int, bool) {
+	due := -1
+	for i := level; i < len(steps); i++ {
+		if !stepDue(steps[i], notifiedAt).After(now) {
+			due = i
+		}
+	}
+	if due < 0 || now.Sub(stepDue(steps[due], notifiedAt)) > escalationGrace {
+		return 0, false
+	}
+	return due, true
+}
+
+// runEscalations sends the due escalation steps of unchecked alerts
+func runEscalations() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := database.GetMonitorsCollection().Find(ctx, bson.M{
+		"escalationPolicyId": bson.M{"$ne": nil},
+		"alertsEnabled":      true,
+	})
+	if err != nil {
+		log.Printf("[notifier] Failed to query monitors with escalation policies: %v", err)
+		return
+	}
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		log.Printf("[notifier] Failed to decode monitors with escalation policies: %v", err)
+		return
+	}
+	if len(monitors) == 0 {
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(monitors))
+	for _, m := range monitors {
+		ids = append(ids, *m.EscalationPolicyID)
+	}
+	cursor, err = database.GetEscalationPoliciesCollection().Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		log.Printf("[notifier] Failed to query escalation policies: %v", err)
+		return
+	}
+	var policies []models.EscalationPolicy
+	if err := cursor.All(ctx, &policies); err != nil {
+		log.Printf("[notifier] Failed to decode escalation policies: %v", err)
+		return
+	}
+	byID := map[primitive.ObjectID]models.EscalationPolicy{}
+	for _, p := range policies {
+		byID[p.ID] = p
+	}
+
+	now := time.Now()
+	for _, m := range monitors {
+		policy, ok := byID[*m.EscalationPolicyID]
+		if !ok || policy.UserID != m.UserID || len(policy.Steps) == 0 {
+			continue
+		}
+		if err := escalateMonitor(ctx, m, policy, now); err != nil {
+			log.Printf("[notifier] Failed to escalate alerts of monitor %s: %v", m.ID.Hex(), err)
+		}
+	}
+}
+
+// escalateMonitor sends the due steps of a monitor's unchecked alerts.
+// The steps count from when an alert's own notification was first sent;
+// alerts not yet notified, such as those held for a digest or for quiet
+// hours, are not escalated.
+func escalateMonitor(ctx context.Context, m models.Monitor, policy models.EscalationPolicy, now time.Time) error {
+	steps := policy.Steps
+	cursor, err := database.GetAlertsCollection().Find(ctx, escalationCandidates(m.ID, steps, now))
+	if err != nil {
+		return err
+	}
+	var alerts []models.Alert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	notified, err := firstNotified(ctx, alerts)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		notifiedAt, ok := notified[alert.ID]
+		if !ok {
+			continue
+		}
+
+		level, ok := dueStep(steps, alert.EscalationLevel, notifiedAt, now)
+		if !ok {
+			continue
+		}
+		if err := escalate(ctx, alert, m, steps[level], level+1, now); err != nil {
+			log.Printf("[notifier] Failed to escalate alert %s: %v", alert.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// escalationCandidates matches a monitor's alerts that may have a step
+// due at now: unchecked, not archived, with steps left, and received late
+// enough for a step to still be within its grace period
+func escalationCandidates(monitorID primitive.ObjectID, steps []models.EscalationStep, now time.Time) bson.M {
+	oldest := now.Add(-time.Duration(steps[len(steps)-1].AfterMinutes)*time.Minute - escalationGrace - notifyLookback)
+	newest := now.Add(-time.Duration(steps[0].AfterMinutes) * time.Minute)
+	return bson.M{
+		"monitorId":       monitorID,
+		"checked":         false,
+		"archived":        bson.M{"$ne": true},
+		"escalationLevel": bson.M{"$not": bson.M{"$gte": len(steps)}},
+		"receivedAt":      bson.M{"$gte": oldest, "$lte": newest},
+	}
+}
+
+// firstNotified returns when each alert's own notification was first sent
+// on any channel. Alerts without a sent delivery are left out.
+func firstNotified(ctx context.Context, alerts []models.Alert) (map[primitive.ObjectID]time.Time, error) {
+	ids := make([]primitive.ObjectID, 0, len(alerts))
+	for _, alert := range alerts {
+		ids = append(ids, alert.ID)
+	}
+
+	cursor, err := database.GetDeliveriesCollection().Find(ctx, bson.M{
+		"alertId":    bson.M{"$in": ids},
+		"escalation": bson.M{"$exists": false},
+		"status":     StatusSent,
+	}, options.Find().SetProjection(bson.M{"alertId": 1, "sentAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []models.Delivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	notified := map[primitive.ObjectID]time.Time{}
+	for _, delivery := range deliveries {
+		if delivery.SentAt == nil {
+			continue
+		}
+		if first, ok := notified[delivery.AlertID]; !ok || delivery.SentAt.Before(first) {
+			notified[delivery.AlertID] = *delivery.SentAt
+		}
+	}
+	return notified, nil
+}
+
+// escalate moves an alert to escalation level and notifies its step. The
+// alert is claimed first, so a step is sent once even when the alert was
+// checked or escalated by another instance in the meantime. Escalations
+// ignore quiet hours and digests: they exist to get someone's attention.
+func escalate(ctx context.Context, alert models.Alert, m models.Monitor, step models.EscalationStep, level int, now time.Time) error {
+	filter, update := escalationClaim(alert, level, now)
+	result, err := database.GetAlertsCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return nil
+	}
+
+	for _, delivery := range stepDeliveries(newDeliveries(ctx, alert, m, step.Channel), step) {
+		delivery.Escalation = level
+		if _, err := database.GetDeliveriesCollection().InsertOne(ctx, delivery); err != nil {
+			log.Printf("[notifier] Failed to record %s escalation for alert %s: %v", step.Channel, alert.ID.Hex(), err)
+			continue
+		}
+		enqueue(delivery.ID)
+	}
+	log.Printf("[notifier] Escalated alert %s to step %d (%s)", alert.ID.Hex(), level, step.Channel)
+	return nil
+}
+
+// escalationClaim returns the update that moves an alert to escalation
+// level, matching only while the alert is unchecked and still at the level
+// it was read at
+func escalationClaim(alert models.Alert, level int, now time.Time) (filter, update bson.M) {
+	current := bson.M{"$exists": false}
+	if alert.EscalationLevel > 0 {
+		current = bson.M{"$eq": alert.EscalationLevel}
+	}
+	filter = bson.M{"_id": alert.ID, "checked": false, "escalationLevel": current}
+	update = bson.M{"$set": bson.M{"escalationLevel": level, "escalatedAt": now}}
+	return filter, update
+}
+
+// stepDeliveries narrows the deliveries built for a step's channel type to
+// the channel the step names, if any
+func stepDeliveries(deliveries []models.Delivery, step models.EscalationStep) []models.Delivery {
+	if step.ChannelID == nil {
+		return deliveries
+	}
+	for _, delivery := range deliveries {
+		if delivery.ChannelID != nil && *delivery.ChannelID == *step.ChannelID {
+			return []models.Delivery{delivery}
+		}
+	}
+
+	// The channel was disabled or removed; sending records why
+	delivery := deliveries[0]
+	delivery.ChannelID = step.ChannelID
+	return []models.Delivery{delivery}
+}
@@ -0,0 +1,167 @@
+package notifier
+
+import (
+	"justping/backend/internal/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDueStep(t *testing.T) {
+	notifiedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	steps := []models.EscalationStep{
+		{AfterMinutes: 10, Channel: ChannelEmail},
+		{AfterMinutes: 30, Channel: ChannelSlack},
+		{AfterMinutes: 60, Channel: ChannelTelegram},
+	}
+	after := func(d time.Duration) time.Time { return notifiedAt.Add(d) }
+
+	tests := []struct {
+		name     string
+		steps    []models.EscalationStep
+		level    int
+		now      time.Time
+		wantStep int
+		wantDue  bool
+	}{
+		{"before the first step", steps, 0, after(9 * time.Minute), 0, false},
+		{"first step due", steps, 0, after(10 * time.Minute), 0, true},
+		{"first step already sent", steps, 1, after(20 * time.Minute), 0, false},
+		{"second step due", steps, 1, after(30 * time.Minute), 1, true},
+		{"missed steps are skipped", steps, 0, after(45 * time.Minute), 1, true},
+		{"latest of all due steps", steps, 0, after(65 * time.Minute), 2, true},
+		{"all steps sent", steps, 3, after(2 * time.Hour), 0, false},
+		{"late within the grace period", steps, 2, after(60*time.Minute + time.Hour), 2, true},
+		{"past the grace period", steps, 2, after(60*time.Minute + time.Hour + time.Second), 0, false},
+		{"older steps past grace are not sent either", steps[:1], 0, after(10*time.Minute + 2*time.Hour), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := dueStep(tt.steps, tt.level, notifiedAt, tt.now)
+			if ok != tt.wantDue || got != tt.wantStep {
+				t.Errorf("dueStep(level %d, %v after) = %d, %t; want %d, %t",
+					tt.level, tt.now.Sub(notifiedAt), got, ok, tt.wantStep, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestEscalationCandidates(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	monitorID := primitive.NewObjectID()
+	steps := []models.EscalationStep{{AfterMinutes: 10}, {AfterMinutes: 60}}
+
+	filter := escalationCandidates(monitorID, steps, now)
+
+	if filter["monitorId"] != monitorID {
+		t.Errorf("monitorId = %v, want %v", filter["monitorId"], monitorID)
+	}
+	// Checking an alert stops its escalation
+	if filter["checked"] != false {
+		t.Errorf("checked = %v, want false", filter["checked"])
+	}
+	if archived := filter["archived"].(bson.M); archived["$ne"] != true {
+		t.Errorf("archived = %v, want archived alerts left out", archived)
+	}
+	level := filter["escalationLevel"].(bson.M)["$not"].(bson.M)
+	if level["$gte"] != 2 {
+		t.Errorf("escalationLevel = %v, want alerts with all steps sent left out", level)
+	}
+
+	received := filter["receivedAt"].(bson.M)
+	if want := now.Add(-10 * time.Minute); received["$lte"] != want {
+		t.Errorf("receivedAt $lte = %v, want %v", received["$lte"], want)
+	}
+	if want := now.Add(-time.Hour - escalationGrace - notifyLookback); received["$gte"] != want {
+		t.Errorf("receivedAt $gte = %v, want %v", received["$gte"], want)
+	}
+}
+
+func TestEscalationClaim(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name      string
+		level     int
+		wantMatch bson.M
+	}{
+		{"first step", 0, bson.M{"$exists": false}},
+		{"later step", 2, bson.M{"$eq": 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := models.Alert{ID: id}
+			alert.EscalationLevel = tt.level
+
+			filter, update := escalationClaim(alert, tt.level+1, now)
+
+			if filter["_id"] != id {
+				t.Errorf("_id = %v, want %v", filter["_id"], id)
+			}
+			// An alert checked since it was read is not escalated
+			if filter["checked"] != false {
+				t.Errorf("checked = %v, want false", filter["checked"])
+			}
+			// Nor is one another instance already moved on
+			current := filter["escalationLevel"].(bson.M)
+			if len(current) != 1 || current["$exists"] != tt.wantMatch["$exists"] || current["$eq"] != tt.wantMatch["$eq"] {
+				t.Errorf("escalationLevel = %v, want %v", current, tt.wantMatch)
+			}
+
+			set := update["$set"].(bson.M)
+			if set["escalationLevel"] != tt.level+1 || set["escalatedAt"] != now {
+				t.Errorf("$set = %v, want level %d at %v", set, tt.level+1, now)
+			}
+		})
+	}
+}
+
+func TestStepDeliveries(t *testing.T) {
+	slackA, slackB, removed := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	alertID := primitive.NewObjectID()
+	delivery := func(channelID *primitive.ObjectID) models.Delivery {
+		return models.Delivery{ID: primitive.NewObjectID(), AlertID: alertID, Channel: ChannelSlack, ChannelID: channelID, Status: StatusPending}
+	}
+	slack := []models.Delivery{delivery(&slackA), delivery(&slackB)}
+	email := []models.Delivery{delivery(nil)}
+
+	tests := []struct {
+		name       string
+		deliveries []models.Delivery
+		step       models.EscalationStep
+		want       []*primitive.ObjectID
+	}{
+		{"every channel of the type", slack, models.EscalationStep{Channel: ChannelSlack}, []*primitive.ObjectID{&slackA, &slackB}},
+		{"the named channel", slack, models.EscalationStep{Channel: ChannelSlack, ChannelID: &slackB}, []*primitive.ObjectID{&slackB}},
+		{"a removed channel is still recorded", slack, models.EscalationStep{Channel: ChannelSlack, ChannelID: &removed}, []*primitive.ObjectID{&removed}},
+		{"a channel of a user without any", email, models.EscalationStep{Channel: ChannelSlack, ChannelID: &removed}, []*primitive.ObjectID{&removed}},
+		{"channel types without channels", email, models.EscalationStep{Channel: ChannelEmail}, []*primitive.ObjectID{nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stepDeliveries(tt.deliveries, tt.step)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d deliveries, want %d", len(got), len(tt.want))
+			}
+			for i, d := range got {
+				if (d.ChannelID == nil) != (tt.want[i] == nil) || (d.ChannelID != nil && *d.ChannelID != *tt.want[i]) {
+					t.Errorf("delivery %d channel = %v, want %v", i, d.ChannelID, tt.want[i])
+				}
+				if d.AlertID != alertID || d.Status != StatusPending {
+					t.Errorf("delivery %d = %+v, want a pending delivery of the alert", i, d)
+				}
+			}
+		})
+	}
+
+	// Narrowing to a removed channel must not point the shared slice at it
+	if *slack[0].ChannelID != slackA {
+		t.Errorf("stepDeliveries changed its input")
+	}
+}
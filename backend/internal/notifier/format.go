@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"fmt"
+	"justping/backend/internal/models"
+	"os"
+	"strings"
+)
+
+// maxExcerptLines caps how many changed lines a notification shows
+const maxExcerptLines = 20
+
+// maxDigestMonitors caps how many monitors a chat digest lists
+const maxDigestMonitors = 10
+
+// appBaseURL is the client URL used for links back to alerts
+func appBaseURL() string {
+	appURL := os.Getenv("APP_BASE_URL")
+	if appURL == "" {
+		appURL = "http://localhost:5173"
+	}
+	return strings.TrimRight(appURL, "/")
+}
+
+// alertLink points at the alert in the client
+func alertLink(alert models.Alert) string {
+	return fmt.Sprintf("%s/navigate/alerts?alert=%s", appBaseURL(), alert.ID.Hex())
+}
+
+// alertsLink points at the alerts page in the client
+func alertsLink() string {
+	return appBaseURL() + "/navigate/alerts"
+}
+
+func monitorName(n Notification) string {
+	if n.Monitor.WebsiteName != "" {
+		return n.Monitor.WebsiteName
+	}
+	if n.Alert.Title != "" {
+		return n.Alert.Title
+	}
+	return pageURL(n)
+}
+
+// headline opens an alert notification, e.g. "Change detected: Pricing"
+func headline(n Notification) string {
+	if n.Escalation > 0 {
+		return "Still unchecked: " + monitorName(n)
+	}
+	return "Change detected: " + monitorName(n)
+}
+
+func pageURL(n Notification) string {
+	if n.Alert.WatchURL != "" {
+		return n.Alert.WatchURL
+	}
+	return n.Monitor.URL
+}
+
+func summary(alert models.Alert) string {
+	if alert.Summary != "" {
+		return alert.Summary
+	}
+	return "Content changed"
+}
+
+// excerpt returns up to limit changed lines of an alert, added lines first
+// and marked "+ " or "- ", and how many more were left out. Alerts
+// without line lists fall back to their diff text.
+func excerpt(alert models.Alert, limit int) ([]string, int) {
+	var lines []string
+	for _, line := range alert.Added {
+		lines = append(lines, "+ "+line)
+	}
+	for _, line := range alert.Removed {
+		lines = append(lines, "- "+line)
+	}
+	if len(lines) == 0 && alert.Diff != "" {
+		lines = strings.Split(strings.TrimRight(alert.Diff, "\n"), "\n")
+	}
+	if len(lines) > limit {
+		return lines[:limit], len(lines) - limit
+	}
+	return lines, 0
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n - len("…")
+	if cut <= 0 {
+		return ""
+	}
+	for cut > 0 && (s[cut]&0xC0) == 0x80 {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// countOf formats n with noun, pluralised with "s" when needed
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// digestTitle names a digest, e.g. "Daily digest: 5 changes on 2 monitors"
+func digestTitle(d DigestNotification) string {
+	kind := "Daily digest"
+	switch d.Frequency {
+	case DigestWeekly:
+		kind = "Weekly digest"
+	case DigestQuiet:
+		kind = "During quiet hours"
+	}
+	return fmt.Sprintf("%s: %s on %s", kind, countOf(d.Changes(), "change"), countOf(len(d.Monitors), "monitor"))
+}
+
+// digestPeriod describes the period a digest covers in the user's timezone
+func digestPeriod(d DigestNotification) string {
+	start, end := d.PeriodStart.In(d.Location), d.PeriodEnd.In(d.Location)
+	return fmt.Sprintf("%s – %s", start.Format("2 Jan 15:04"), end.Format("2 Jan 15:04 MST"))
+}
+
+// latest is the monitor's newest alert as a Notification, for the
+// helpers above
+func (m DigestMonitor) latest() Notification {
+	n := Notification{Monitor: m.Monitor}
+	if len(m.Alerts) > 0 {
+		n.Alert = m.Alerts[0]
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"justping/backend/internal/models"
+	"log"
+	"sync"
+)
+
+// Notification is what a Notifier delivers: one alert and its monitor
+type Notification struct {
+	Alert   models.Alert
+	Monitor models.Monitor
+	Test    bool // A sample alert sent to check a channel's setup
+
+	Escalation int // Escalation step that sent the alert again, from 1
+}
+
+// Notifier sends notifications over one channel, such as email or Slack.
+// Send should return once the notification was accepted by the channel;
+// an error marks the attempt as failed.
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// ErrNoNotifier is recorded for deliveries to a channel nothing handles
+var ErrNoNotifier = errors.New("no notifier registered for channel")
+
+var (
+	notifiers   = map[string]Notifier{}
+	notifiersMu sync.RWMutex
+
+	// unhandledChannels holds the channels already logged as having no
+	// notifier
+	unhandledChannels sync.Map
+)
+
+// Register makes n available under n.Name(), replacing any notifier
+// registered under the same name
+func Register(n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[n.Name()] = n
+}
+
+func lookup(name string) (Notifier, bool) {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+	n, ok := notifiers[name]
+	return n, ok
+}
+
+// channelsFor returns the channels a monitor's alerts are sent to. Channels
+// without a notifier, such as email without SMTP settings, are left out and
+// logged once, rather than failing a delivery for every alert.
+func channelsFor(m models.Monitor) []string {
+	if !m.AlertsEnabled || m.NotificationMethod == "" {
+		return nil
+	}
+	if _, ok := lookup(m.NotificationMethod); !ok {
+		if _, logged := unhandledChannels.LoadOrStore(m.NotificationMethod, true); !logged {
+			log.Printf("[notifier] No notifier for channel %q, its alerts are not sent", m.NotificationMethod)
+		}
+		return nil
+	}
+	return []string{m.NotificationMethod}
+}
+
+// registerFromEnv registers every channel that is configured in the
+// environment. Chat and webhook channels need no setup here; users add
+// their own URLs.
+func registerFromEnv() {
+	Register(&chatNotifier{channelType: ChannelSlack, render: renderSlack, renderDigest: renderSlackDigest})
+	Register(&chatNotifier{channelType: ChannelDiscord, render: renderDiscord, renderDigest: renderDiscordDigest})
+	Register(newWebhookNotifier())
+
+	if telegram = NewTelegramNotifierFromEnv(); telegram != nil {
+		Register(telegram)
+		log.Printf("[notifier] Telegram bot enabled")
+	}
+
+	email, err := NewEmailNotifierFromEnv()
+	switch {
+	case err != nil:
+		log.Printf("[notifier] Email disabled: %v", err)
+	case email != nil:
+		Register(email)
+		log.Printf("[notifier] Email via %s:%s (%s)", email.Host, email.Port, email.TLSMode)
+	}
+}
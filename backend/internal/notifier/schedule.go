@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"fmt"
+	"justping/backend/internal/models"
+	"time"
+)
+
+// Schedule modes
+const (
+	ScheduleAlways  = "always"  // Alerts are sent at any time
+	ScheduleWindows = "windows" // Alerts are only sent inside the windows
+)
+
+// What happens to alerts arriving in quiet hours
+const (
+	QuietDefer  = "defer"  // Each alert is sent when the next window opens
+	QuietDigest = "digest" // One summary of them is sent when it opens
+)
+
+// DigestQuiet is the frequency of digests that roll up quiet-hours alerts
+const DigestQuiet = "quiet"
+
+// ValidateSchedule checks a schedule from a request. allowInherit permits
+// an empty mode, which monitors use to follow the user's schedule.
+func ValidateSchedule(s *models.NotificationSchedule, allowInherit bool) error {
+	switch s.Mode {
+	case ScheduleAlways:
+	case ScheduleWindows:
+		if len(s.Windows) == 0 {
+			return fmt.Errorf("a windows schedule needs at least one window")
+		}
+	case "":
+		if !allowInherit {
+			return fmt.Errorf("schedule mode must be always or windows")
+		}
+		return nil
+	default:
+		return fmt.Errorf("schedule mode must be always or windows")
+	}
+
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("invalid schedule timezone %q", s.Timezone)
+		}
+	}
+	if s.QuietAction != "" && s.QuietAction != QuietDefer && s.QuietAction != QuietDigest {
+		return fmt.Errorf("quietAction must be defer or digest")
+	}
+	for _, w := range s.Windows {
+		if _, err := time.Parse("15:04", w.Start); err != nil {
+			return fmt.Errorf("invalid window start %q: use HH:MM", w.Start)
+		}
+		if _, err := time.Parse("15:04", w.End); err != nil {
+			return fmt.Errorf("invalid window end %q: use HH:MM", w.End)
+		}
+		for _, day := range w.Days {
+			if day < 0 || day > 6 {
+				return fmt.Errorf("invalid window day %d: use 0 (Sunday) to 6 (Saturday)", day)
+			}
+		}
+	}
+	return nil
+}
+
+// monitorSchedule returns the schedule a monitor's alerts follow, with
+// the timezone it is evaluated in. Nil means alerts are always sent.
+func monitorSchedule(m models.Monitor, settings models.NotificationSettings) (*models.NotificationSchedule, *time.Location) {
+	schedule := settings.Schedule
+	if m.Schedule != nil && m.Schedule.Mode != "" {
+		schedule = m.Schedule
+	}
+	if schedule == nil || schedule.Mode != ScheduleWindows {
+		return nil, nil
+	}
+
+	loc := settingsLocation(settings)
+	if schedule.Timezone != "" {
+		if l, err := time.LoadLocation(schedule.Timezone); err == nil {
+			loc = l
+		}
+	}
+	return schedule, loc
+}
+
+// nextAllowed returns t when the schedule lets alerts through at t, and
+// otherwise when its next window opens. A schedule whose windows never
+// open does not hold anything back.
+func nextAllowed(s *models.NotificationSchedule, loc *time.Location, t time.Time) time.Time {
+	if s == nil {
+		return t
+	}
+
+	local := t.In(loc)
+	var next time.Time
+	// Start a day early for windows spanning midnight; a week ahead
+	// covers every window at least once
+	for offset := -1; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		for _, w := range s.Windows {
+			if !windowOnDay(w, day.Weekday()) {
+				continue
+			}
+			start, end := windowBounds(w, day)
+			if !local.Before(start) && local.Before(end) {
+				return t
+			}
+			if start.After(local) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	if next.IsZero() {
+		return t
+	}
+	return next
+}
+
+func windowOnDay(w models.ScheduleWindow, weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if time.Weekday(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// windowBounds returns when w opens and closes on day. An end at or
+// before the start closes the next day.
+func windowBounds(w models.ScheduleWindow, day time.Time) (time.Time, time.Time) {
+	startAt, _ := time.Parse("15:04", w.Start)
+	endAt, _ := time.Parse("15:04", w.End)
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), startAt.Hour(), startAt.Minute(), 0, 0, day.Location())
+	end := time.Date(day.Year(), day.Month(), day.Day(), endAt.Hour(), endAt.Minute(), 0, 0, day.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
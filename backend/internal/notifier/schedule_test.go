@@ -0,0 +1,112 @@
+package notifier
+
+import (
+	"justping/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	weekdays := models.ScheduleWindow{Days: []int{1, 2, 3, 4, 5}, Start: "09:00", End: "17:00"}
+
+	tests := []struct {
+		name         string
+		schedule     models.NotificationSchedule
+		allowInherit bool
+		wantErr      bool
+	}{
+		{"always", models.NotificationSchedule{Mode: ScheduleAlways}, false, false},
+		{"windows", models.NotificationSchedule{Mode: ScheduleWindows, Windows: []models.ScheduleWindow{weekdays}, QuietAction: QuietDigest}, false, false},
+		{"windows with timezone", models.NotificationSchedule{Mode: ScheduleWindows, Timezone: "Europe/Berlin", Windows: []models.ScheduleWindow{weekdays}}, false, false},
+		{"inherit on a monitor", models.NotificationSchedule{}, true, false},
+		{"inherit for a user", models.NotificationSchedule{}, false, true},
+		{"unknown mode", models.NotificationSchedule{Mode: "sometimes"}, true, true},
+		{"windows without windows", models.NotificationSchedule{Mode: ScheduleWindows}, false, true},
+		{"unknown timezone", models.NotificationSchedule{Mode: ScheduleAlways, Timezone: "Mars/Olympus"}, false, true},
+		{"unknown quiet action", models.NotificationSchedule{Mode: ScheduleAlways, QuietAction: "drop"}, false, true},
+		{"bad start", models.NotificationSchedule{Mode: ScheduleWindows, Windows: []models.ScheduleWindow{{Start: "9am", End: "17:00"}}}, false, true},
+		{"bad end", models.NotificationSchedule{Mode: ScheduleWindows, Windows: []models.ScheduleWindow{{Start: "09:00", End: "24:00"}}}, false, true},
+		{"bad day", models.NotificationSchedule{Mode: ScheduleWindows, Windows: []models.ScheduleWindow{{Days: []int{7}, Start: "09:00", End: "17:00"}}}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateSchedule(&tt.schedule, tt.allowInherit); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchedule() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowBounds(t *testing.T) {
+	day := time.Date(2026, time.March, 4, 0, 0, 0, 0, time.UTC)
+	at := func(d, hour, min int) time.Time {
+		return time.Date(2026, time.March, d, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name               string
+		window             models.ScheduleWindow
+		wantStart, wantEnd time.Time
+	}{
+		{"same day", models.ScheduleWindow{Start: "09:00", End: "17:30"}, at(4, 9, 0), at(4, 17, 30)},
+		{"across midnight", models.ScheduleWindow{Start: "22:00", End: "06:00"}, at(4, 22, 0), at(5, 6, 0)},
+		{"whole day", models.ScheduleWindow{Start: "00:00", End: "00:00"}, at(4, 0, 0), at(5, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := windowBounds(tt.window, day)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("windowBounds() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestNextAllowed(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	// March 2026: the 2nd is a Monday, the 6th a Friday
+	at := func(d, hour, min int) time.Time {
+		return time.Date(2026, time.March, d, hour, min, 0, 0, time.UTC)
+	}
+	schedule := func(windows ...models.ScheduleWindow) *models.NotificationSchedule {
+		return &models.NotificationSchedule{Mode: ScheduleWindows, Windows: windows}
+	}
+	officeHours := schedule(models.ScheduleWindow{Days: []int{1, 2, 3, 4, 5}, Start: "09:00", End: "17:00"})
+	nights := schedule(models.ScheduleWindow{Start: "22:00", End: "06:00"})
+
+	tests := []struct {
+		name     string
+		schedule *models.NotificationSchedule
+		loc      *time.Location
+		t        time.Time
+		want     time.Time
+	}{
+		{"no schedule", nil, nil, at(4, 3, 0), at(4, 3, 0)},
+		{"inside a window", officeHours, time.UTC, at(4, 10, 0), at(4, 10, 0)},
+		{"at the start", officeHours, time.UTC, at(4, 9, 0), at(4, 9, 0)},
+		{"before the start", officeHours, time.UTC, at(4, 8, 0), at(4, 9, 0)},
+		{"at the end", officeHours, time.UTC, at(4, 17, 0), at(5, 9, 0)},
+		{"over the weekend", officeHours, time.UTC, at(6, 18, 0), at(9, 9, 0)},
+		{"after midnight in an overnight window", nights, time.UTC, at(4, 3, 0), at(4, 3, 0)},
+		{"before an overnight window", nights, time.UTC, at(4, 12, 0), at(4, 22, 0)},
+		{"earliest of several windows", schedule(
+			models.ScheduleWindow{Start: "18:00", End: "19:00"},
+			models.ScheduleWindow{Start: "13:00", End: "14:00"},
+		), time.UTC, at(4, 12, 0), at(4, 13, 0)},
+		{"schedule timezone", officeHours, berlin, at(4, 7, 30), at(4, 8, 0)},
+		{"no windows", schedule(), time.UTC, at(4, 3, 0), at(4, 3, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextAllowed(tt.schedule, tt.loc, tt.t); !got.Equal(tt.want) {
+				t.Errorf("nextAllowed(%v) = %v, want %v", tt.t, got.UTC(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Slack rejects section text longer than 3000 characters
+const slackMaxText = 3000
+
+// renderSlack formats an alert as a Slack Block Kit message
+func renderSlack(n Notification) ([]byte, error) {
+	name := monitorName(n)
+	page := pageURL(n)
+	lines, more := excerpt(n.Alert, maxExcerptLines)
+
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": truncate(headline(n), 150), "emoji": true},
+		},
+		{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("<%s|%s>\n%s", page, slackEscape(page), slackEscape(summary(n.Alert)))},
+		},
+	}
+
+	if len(lines) > 0 {
+		snippet := strings.Join(lines, "\n")
+		if more > 0 {
+			snippet += fmt.Sprintf("\n… and %d more changed lines", more)
+		}
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": "```" + truncate(slackEscape(snippet), slackMaxText-6) + "```"},
+		})
+	}
+
+	blocks = append(blocks,
+		map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{{
+				"type": "button",
+				"text": map[string]any{"type": "plain_text", "text": "View alert"},
+				"url":  alertLink(n.Alert),
+			}},
+		},
+		map[string]any{
+			"type": "context",
+			"elements": []map[string]any{{
+				"type": "mrkdwn",
+				"text": "JustPing · " + n.Alert.ReceivedAt.UTC().Format("2 Jan 2006 15:04 UTC"),
+			}},
+		},
+	)
+
+	return json.Marshal(map[string]any{
+		// Shown in notifications and clients without Block Kit
+		"text":   fmt.Sprintf("Change detected: %s (%s)", name, summary(n.Alert)),
+		"blocks": blocks,
+	})
+}
+
+// renderSlackDigest formats a digest as a Slack Block Kit message
+func renderSlackDigest(d DigestNotification) ([]byte, error) {
+	title := digestTitle(d)
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": truncate(title, 150), "emoji": true},
+		},
+		{
+			"type":     "context",
+			"elements": []map[string]any{{"type": "mrkdwn", "text": slackEscape(digestPeriod(d))}},
+		},
+	}
+
+	for i, m := range d.Monitors {
+		if i == maxDigestMonitors {
+			blocks = append(blocks, map[string]any{
+				"type":     "context",
+				"elements": []map[string]any{{"type": "mrkdwn", "text": fmt.Sprintf("… and %s more", countOf(len(d.Monitors)-i, "monitor"))}},
+			})
+			break
+		}
+		n := m.latest()
+		text := fmt.Sprintf("*<%s|%s>* · %s\n%s", alertLink(n.Alert), slackEscape(monitorName(n)), countOf(m.Changes, "change"), slackEscape(summary(n.Alert)))
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": truncate(text, slackMaxText)},
+		})
+	}
+
+	blocks = append(blocks, map[string]any{
+		"type": "actions",
+		"elements": []map[string]any{{
+			"type": "button",
+			"text": map[string]any{"type": "plain_text", "text": "View alerts"},
+			"url":  alertsLink(),
+		}},
+	})
+
+	return json.Marshal(map[string]any{
+		"text":   title,
+		"blocks": blocks,
+	})
+}
+
+// slackEscape escapes the characters Slack treats as markup
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,372 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// telegramMaxText is the Bot API limit for one message
+	telegramMaxText = 4096
+	// telegramLinkTTL is how long a /start code stays valid
+	telegramLinkTTL = 15 * time.Minute
+	// telegramPollTimeout is the long-polling wait of getUpdates
+	telegramPollTimeout = 25 * time.Second
+)
+
+// ErrTelegramDisabled is returned when TELEGRAM_BOT_TOKEN is not set
+var ErrTelegramDisabled = errors.New("telegram is not configured")
+
+// telegram is the configured bot, nil when Telegram is disabled
+var telegram *TelegramNotifier
+
+// TelegramNotifier sends alerts to linked chats through the Bot API and
+// listens for /start messages that link new chats
+type TelegramNotifier struct {
+	Token      string
+	Username   string // Bot username without "@", used for t.me links
+	APIBaseURL string
+	client     *http.Client
+	nextUpdate int64
+	// link attaches a chat to the channel whose unexpired code it sent,
+	// reporting false when there is none; linkTelegramChat outside tests
+	link func(ctx context.Context, code string, chatID int64, name string, now time.Time) (bool, error)
+}
+
+// NewTelegramNotifierFromEnv configures the bot from TELEGRAM_* variables.
+// It returns nil when TELEGRAM_BOT_TOKEN is not set.
+func NewTelegramNotifierFromEnv() *TelegramNotifier {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil
+	}
+
+	apiBaseURL := os.Getenv("TELEGRAM_API_BASE_URL")
+	if apiBaseURL == "" {
+		apiBaseURL = "https://api.telegram.org"
+	}
+
+	return &TelegramNotifier{
+		Token:      token,
+		Username:   strings.TrimPrefix(os.Getenv("TELEGRAM_BOT_USERNAME"), "@"),
+		APIBaseURL: strings.TrimRight(apiBaseURL, "/"),
+		client:     &http.Client{Timeout: telegramPollTimeout + 10*time.Second},
+		link:       linkTelegramChat,
+	}
+}
+
+func (t *TelegramNotifier) Name() string {
+	return ChannelTelegram
+}
+
+func (t *TelegramNotifier) Send(ctx context.Context, n Notification) error {
+	return sendToUserChannels(ctx, t, ChannelTelegram, n)
+}
+
+func (t *TelegramNotifier) sendTo(ctx context.Context, channel models.Channel, n Notification) error {
+	if channel.ChatID == 0 {
+		return errors.New("chat is not linked yet")
+	}
+	return t.sendMessage(ctx, channel.ChatID, renderTelegram(n))
+}
+
+// renderTelegram formats an alert as a Telegram HTML message
+func renderTelegram(n Notification) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>%s</b>\n", html.EscapeString(headline(n)))
+	fmt.Fprintf(&sb, "%s\n%s\n", html.EscapeString(pageURL(n)), html.EscapeString(summary(n.Alert)))
+
+	link := fmt.Sprintf("\n<a href=\"%s\">View alert</a>", html.EscapeString(alertLink(n.Alert)))
+
+	lines, more := excerpt(n.Alert, maxExcerptLines)
+	if len(lines) > 0 {
+		snippet := strings.Join(lines, "\n")
+		if more > 0 {
+			snippet += fmt.Sprintf("\n… and %d more changed lines", more)
+		}
+		// Leave room for the tags and the link
+		room := telegramMaxText - sb.Len() - len(link) - len("\n<pre></pre>")
+		fmt.Fprintf(&sb, "\n<pre>%s</pre>", escapeTruncated(snippet, room))
+	}
+
+	sb.WriteString(link)
+	return sb.String()
+}
+
+// escapeTruncated HTML-escapes s, cut so the escaped text is at most n
+// bytes. It cuts before escaping, so no entity is split.
+func escapeTruncated(s string, n int) string {
+	if escaped := html.EscapeString(s); len(escaped) <= n {
+		return escaped
+	}
+	limit := n - len("…")
+	size := 0
+	for i, r := range s {
+		size += len(html.EscapeString(string(r)))
+		if size > limit {
+			return html.EscapeString(s[:i]) + "…"
+		}
+	}
+	return html.EscapeString(s)
+}
+
+func (t *TelegramNotifier) sendDigestTo(ctx context.Context, channel models.Channel, d DigestNotification) error {
+	if channel.ChatID == 0 {
+		return errors.New("chat is not linked yet")
+	}
+	return t.sendMessage(ctx, channel.ChatID, renderTelegramDigest(d))
+}
+
+// renderTelegramDigest formats a digest as a Telegram HTML message,
+// listing as many monitors as fit
+func renderTelegramDigest(d DigestNotification) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>%s</b>\n%s\n", html.EscapeString(digestTitle(d)), html.EscapeString(digestPeriod(d)))
+
+	link := fmt.Sprintf("\n<a href=\"%s\">View alerts</a>", html.EscapeString(alertsLink()))
+	for i, m := range d.Monitors {
+		n := m.latest()
+		entry := fmt.Sprintf("\n• <a href=\"%s\">%s</a> · %s\n%s\n",
+			html.EscapeString(alertLink(n.Alert)), html.EscapeString(monitorName(n)),
+			countOf(m.Changes, "change"), html.EscapeString(summary(n.Alert)))
+
+		// Keep room for the "more" line and the link
+		if i == maxDigestMonitors || sb.Len()+len(entry)+len(link)+64 > telegramMaxText {
+			fmt.Fprintf(&sb, "\n… and %s more\n", countOf(len(d.Monitors)-i, "monitor"))
+			break
+		}
+		sb.WriteString(entry)
+	}
+
+	sb.WriteString(link)
+	return sb.String()
+}
+
+// telegramResponse is the envelope of every Bot API response
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// call invokes a Bot API method with JSON params and decodes its result
+func (t *TelegramNotifier) call(ctx context.Context, method string, params any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", t.APIBaseURL, t.Token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The error includes the URL, which contains the token
+		return fmt.Errorf("telegram %s: %s", method, strings.ReplaceAll(err.Error(), t.Token, "<token>"))
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var tr telegramResponse
+	if err := json.Unmarshal(respBody, &tr); err != nil {
+		return fmt.Errorf("telegram %s returned %d", method, resp.StatusCode)
+	}
+	if !tr.OK {
+		return fmt.Errorf("telegram %s: %s", method, tr.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(tr.Result, result)
+	}
+	return nil
+}
+
+func (t *TelegramNotifier) sendMessage(ctx context.Context, chatID int64, text string) error {
+	return t.call(ctx, "sendMessage", map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}, nil)
+}
+
+// telegramUpdate holds the parts of an update the link flow needs
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID        int64  `json:"id"`
+			Type      string `json:"type"`
+			Title     string `json:"title"`
+			Username  string `json:"username"`
+			FirstName string `json:"first_name"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// poll long-polls getUpdates for /start messages until ctx is cancelled
+func (t *TelegramNotifier) poll(ctx context.Context) {
+	for {
+		var updates []telegramUpdate
+		err := t.call(ctx, "getUpdates", map[string]any{
+			"offset":          t.nextUpdate,
+			"timeout":         int(telegramPollTimeout.Seconds()),
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[notifier] Telegram polling failed: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			t.nextUpdate = update.UpdateID + 1
+			if update.Message != nil {
+				t.handleMessage(ctx, update)
+			}
+		}
+	}
+}
+
+// handleMessage links a chat when it sends /start with a valid code
+func (t *TelegramNotifier) handleMessage(ctx context.Context, update telegramUpdate) {
+	msg := update.Message
+	command, code, _ := strings.Cut(strings.TrimSpace(msg.Text), " ")
+	command, _, _ = strings.Cut(command, "@") // "/start@JustPingBot" in groups
+	if command != "/start" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	reply := "Open the Telegram link on JustPing's Integrations page to connect this chat."
+	if code = strings.TrimSpace(code); code != "" {
+		name := msg.Chat.Title
+		if name == "" && msg.Chat.Username != "" {
+			name = "@" + msg.Chat.Username
+		}
+		if name == "" {
+			name = msg.Chat.FirstName
+		}
+
+		linked, err := t.link(ctx, code, msg.Chat.ID, name, time.Now())
+		switch {
+		case err != nil:
+			log.Printf("[notifier] Failed to link Telegram chat %d: %v", msg.Chat.ID, err)
+			reply = "Something went wrong linking this chat. Please try again."
+		case !linked:
+			reply = "This link has expired or was already used. Create a new one on JustPing's Integrations page."
+		default:
+			log.Printf("[notifier] Linked Telegram chat %d", msg.Chat.ID)
+			reply = "This chat is now linked to JustPing. Alerts for monitors using Telegram will arrive here."
+		}
+	}
+
+	if err := t.sendMessage(ctx, msg.Chat.ID, html.EscapeString(reply)); err != nil {
+		log.Printf("[notifier] Failed to reply to Telegram chat %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// linkTelegramChat stores chatID on the Telegram channel waiting for code
+// and enables it
+func linkTelegramChat(ctx context.Context, code string, chatID int64, name string, now time.Time) (bool, error) {
+	res, err := database.GetChannelsCollection().UpdateOne(ctx,
+		telegramLinkFilter(code, now),
+		bson.M{
+			"$set":   bson.M{"chatId": chatID, "name": "Telegram " + name, "enabled": true, "updatedAt": now},
+			"$unset": bson.M{"linkCode": "", "linkExpiresAt": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// telegramLinkFilter matches the channel a /start code was issued for,
+// as long as the code has not expired at now
+func telegramLinkFilter(code string, now time.Time) bson.M {
+	return bson.M{"type": ChannelTelegram, "linkCode": code, "linkExpiresAt": bson.M{"$gt": now}}
+}
+
+// newTelegramLinkCode returns a random /start code and when it expires
+func newTelegramLinkCode(now time.Time) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate link code: %w", err)
+	}
+	return hex.EncodeToString(buf), now.Add(telegramLinkTTL), nil
+}
+
+// TelegramLink starts linking a Telegram chat to userID. The user's
+// pending Telegram channel is reused, so asking again replaces the code.
+func TelegramLink(ctx context.Context, userID string) (*models.TelegramLinkResponse, error) {
+	if telegram == nil {
+		return nil, ErrTelegramDisabled
+	}
+
+	now := time.Now()
+	code, expiresAt, err := newTelegramLinkCode(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var channel models.Channel
+	err = database.GetChannelsCollection().FindOneAndUpdate(ctx,
+		bson.M{"userId": userID, "type": ChannelTelegram, "chatId": bson.M{"$exists": false}},
+		bson.M{
+			"$set": bson.M{"linkCode": code, "linkExpiresAt": expiresAt, "updatedAt": now},
+			"$setOnInsert": bson.M{
+				"_id":       primitive.NewObjectID(),
+				"name":      "Telegram (not linked)",
+				"enabled":   false,
+				"createdAt": now,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&channel)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.TelegramLinkResponse{
+		ChannelID: channel.ID,
+		Code:      code,
+		ExpiresAt: expiresAt,
+	}
+	if telegram.Username != "" {
+		link.Link = fmt.Sprintf("https://t.me/%s?start=%s", telegram.Username, code)
+	}
+	return link, nil
+}
@@ -0,0 +1,352 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"justping/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testTelegramToken = "123456:test-token"
+
+// telegramCall is one Bot API request the fake server received
+type telegramCall struct {
+	method string
+	params map[string]any
+}
+
+// startTelegramServer runs a fake Bot API. respond returns the result of
+// each call; a nil result answers ok with an empty result. It returns a
+// notifier pointed at the server and the calls it receives.
+func startTelegramServer(t *testing.T, respond func(call telegramCall) any) (*TelegramNotifier, func() []telegramCall) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []telegramCall
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/bot" + testTelegramToken + "/"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "description": "Not Found"})
+			return
+		}
+
+		call := telegramCall{method: strings.TrimPrefix(r.URL.Path, prefix)}
+		if err := json.NewDecoder(r.Body).Decode(&call.params); err != nil {
+			t.Errorf("%s: invalid JSON body: %v", call.method, err)
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+
+		result := respond(call)
+		if result == nil {
+			result = true
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+
+	tg := &TelegramNotifier{
+		Token:      testTelegramToken,
+		APIBaseURL: srv.URL,
+		client:     srv.Client(),
+		link: func(ctx context.Context, code string, chatID int64, name string, now time.Time) (bool, error) {
+			t.Errorf("unexpected link of chat %d", chatID)
+			return false, nil
+		},
+	}
+	return tg, func() []telegramCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]telegramCall(nil), calls...)
+	}
+}
+
+func TestNewTelegramLinkCode(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	code, expiresAt, err := newTelegramLinkCode(now)
+	if err != nil {
+		t.Fatalf("newTelegramLinkCode: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(code) {
+		t.Errorf("code = %q, want 32 hex characters", code)
+	}
+	if want := now.Add(15 * time.Minute); !expiresAt.Equal(want) {
+		t.Errorf("expiresAt = %v, want %v", expiresAt, want)
+	}
+
+	other, _, err := newTelegramLinkCode(now)
+	if err != nil {
+		t.Fatalf("newTelegramLinkCode: %v", err)
+	}
+	if other == code {
+		t.Errorf("two link codes are both %q", code)
+	}
+}
+
+func TestTelegramLinkExpiry(t *testing.T) {
+	issued := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	_, expiresAt, err := newTelegramLinkCode(issued)
+	if err != nil {
+		t.Fatalf("newTelegramLinkCode: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		usedAfter time.Duration
+		wantValid bool
+	}{
+		{"right away", 0, true},
+		{"before expiry", 14*time.Minute + 59*time.Second, true},
+		{"at expiry", 15 * time.Minute, false},
+		{"after expiry", time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := telegramLinkFilter("abc", issued.Add(tt.usedAfter))
+			if filter["type"] != ChannelTelegram || filter["linkCode"] != "abc" {
+				t.Fatalf("filter does not select the code's Telegram channel: %v", filter)
+			}
+			after := filter["linkExpiresAt"].(bson.M)["$gt"].(time.Time)
+			if got := expiresAt.After(after); got != tt.wantValid {
+				t.Errorf("code used %v after issue matches = %t, want %t", tt.usedAfter, got, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestTelegramPoll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := func(id int64, chatID int64, text string) map[string]any {
+		return map[string]any{
+			"update_id": id,
+			"message": map[string]any{
+				"text": text,
+				"chat": map[string]any{"id": chatID, "type": "private", "first_name": "Ada"},
+			},
+		}
+	}
+
+	polls := 0
+	tg, calls := startTelegramServer(t, func(call telegramCall) any {
+		if call.method != "getUpdates" {
+			return nil
+		}
+		polls++
+		if polls == 1 {
+			return []map[string]any{
+				update(5, 42, "/start good-code"),
+				update(6, 43, "hello"),
+				update(7, 44, "/start@JustPingBot"),
+				update(8, 45, "/start old-code"),
+				{"update_id": 9}, // not a message
+			}
+		}
+		// Stop after the second poll
+		cancel()
+		return []map[string]any{}
+	})
+
+	var linked []string
+	tg.link = func(ctx context.Context, code string, chatID int64, name string, now time.Time) (bool, error) {
+		linked = append(linked, code)
+		if name != "Ada" {
+			t.Errorf("chat %d linked as %q, want Ada", chatID, name)
+		}
+		return code == "good-code", nil
+	}
+
+	tg.poll(ctx)
+
+	if want := []string{"good-code", "old-code"}; strings.Join(linked, ",") != strings.Join(want, ",") {
+		t.Errorf("linked codes = %v, want %v", linked, want)
+	}
+	if tg.nextUpdate != 10 {
+		t.Errorf("nextUpdate = %d, want 10", tg.nextUpdate)
+	}
+
+	replies := map[int64]string{}
+	var offsets []float64
+	for _, call := range calls() {
+		switch call.method {
+		case "getUpdates":
+			offsets = append(offsets, call.params["offset"].(float64))
+			if call.params["timeout"] != float64(25) {
+				t.Errorf("getUpdates timeout = %v, want 25", call.params["timeout"])
+			}
+		case "sendMessage":
+			replies[int64(call.params["chat_id"].(float64))] = call.params["text"].(string)
+		default:
+			t.Errorf("unexpected call %s", call.method)
+		}
+	}
+
+	if len(offsets) != 2 || offsets[0] != 0 || offsets[1] != 10 {
+		t.Errorf("getUpdates offsets = %v, want [0 10]", offsets)
+	}
+
+	wantReplies := map[int64]string{
+		42: "now linked",
+		44: "Open the Telegram link",
+		45: "expired or was already used",
+	}
+	if len(replies) != len(wantReplies) {
+		t.Errorf("replied to %d chats, want %d: %v", len(replies), len(wantReplies), replies)
+	}
+	for chatID, want := range wantReplies {
+		if !strings.Contains(replies[chatID], want) {
+			t.Errorf("reply to chat %d = %q, want it to contain %q", chatID, replies[chatID], want)
+		}
+	}
+}
+
+func TestTelegramPollRetriesAfterErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tg, calls := startTelegramServer(t, func(call telegramCall) any { return nil })
+	tg.Token = "654321:wrong-token"
+
+	done := make(chan struct{})
+	go func() {
+		tg.poll(ctx)
+		close(done)
+	}()
+
+	// The first call fails with 404; poll waits before the next one and
+	// still returns promptly once cancelled
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not return after cancel")
+	}
+	if n := len(calls()); n != 0 {
+		t.Errorf("fake server recorded %d calls for a wrong token", n)
+	}
+}
+
+func TestTelegramCallHidesToken(t *testing.T) {
+	tg := &TelegramNotifier{
+		Token:      testTelegramToken,
+		APIBaseURL: "http://127.0.0.1:1",
+		client:     &http.Client{Timeout: time.Second},
+	}
+	err := tg.sendMessage(context.Background(), 42, "hi")
+	if err == nil {
+		t.Fatal("sendMessage to a closed port succeeded")
+	}
+	if strings.Contains(err.Error(), testTelegramToken) {
+		t.Errorf("error reveals the bot token: %v", err)
+	}
+}
+
+func TestTelegramSendTo(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://app.example")
+
+	tg, calls := startTelegramServer(t, func(call telegramCall) any { return nil })
+	n := chatNotification([]string{"<b>Pro</b> & more"}, nil)
+
+	if err := tg.sendTo(context.Background(), models.Channel{Name: "Telegram Ada"}, n); err == nil {
+		t.Error("sendTo an unlinked chat succeeded")
+	}
+	if err := tg.sendTo(context.Background(), models.Channel{ChatID: 42}, n); err != nil {
+		t.Fatalf("sendTo: %v", err)
+	}
+
+	got := calls()
+	if len(got) != 1 || got[0].method != "sendMessage" {
+		t.Fatalf("calls = %v, want one sendMessage", got)
+	}
+	params := got[0].params
+	if params["chat_id"] != float64(42) || params["parse_mode"] != "HTML" {
+		t.Errorf("sendMessage params = %v", params)
+	}
+	if params["text"] != renderTelegram(n) {
+		t.Errorf("text = %q, want the rendered alert", params["text"])
+	}
+}
+
+func TestRenderTelegram(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://app.example")
+	link := `<a href="https://app.example/navigate/alerts?alert=65f000000000000000000001">View alert</a>`
+
+	escapedName := chatNotification([]string{"a"}, nil)
+	escapedName.Monitor.WebsiteName = "Tom & Jerry <3"
+
+	tests := []struct {
+		name      string
+		n         Notification
+		wantParts []string
+		wantNot   []string
+	}{
+		{
+			name:      "changed lines",
+			n:         chatNotification([]string{"Pro plan: $12"}, []string{"Pro plan: $10"}),
+			wantParts: []string{"<b>Change detected: Pricing</b>", "<pre>+ Pro plan: $12\n- Pro plan: $10</pre>"},
+		},
+		{
+			name:      "no changed lines",
+			n:         chatNotification(nil, nil),
+			wantNot:   []string{"<pre>"},
+			wantParts: []string{"1 line added\n" + "\n" + link},
+		},
+		{
+			name:      "HTML in lines is escaped",
+			n:         chatNotification([]string{`<script>alert("x")</script> & co`}, nil),
+			wantParts: []string{"+ &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; co"},
+			wantNot:   []string{"<script>"},
+		},
+		{
+			name:      "HTML in the name is escaped",
+			n:         escapedName,
+			wantParts: []string{"<b>Change detected: Tom &amp; Jerry &lt;3</b>"},
+		},
+		{
+			name:      "long lines are cut",
+			n:         chatNotification(manyLines(20, 1000), nil),
+			wantParts: []string{"…</pre>"},
+		},
+		{
+			name:      "entities are not split",
+			n:         chatNotification(manyLines(20, 1000)[:1], []string{strings.Repeat("&", 5000)}),
+			wantParts: []string{"&amp;…</pre>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderTelegram(tt.n)
+			if len(got) > telegramMaxText {
+				t.Errorf("message has %d characters, Telegram allows %d", len(got), telegramMaxText)
+			}
+			if !strings.HasSuffix(got, link) {
+				t.Errorf("message does not end with the alert link: %q", got[max(len(got)-120, 0):])
+			}
+			for _, want := range tt.wantParts {
+				if !strings.Contains(got, want) {
+					t.Errorf("message does not contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("message contains %q", unwanted)
+				}
+			}
+		})
+	}
+}
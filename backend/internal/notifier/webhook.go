@@ -0,0 +1,288 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/models"
+	"justping/backend/internal/safehttp"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookPayloadVersion is bumped whenever the payload changes in a way
+// receivers could break on. Adding fields does not bump it.
+const WebhookPayloadVersion = 1
+
+// Webhook events
+const (
+	EventAlertCreated   = "alert.created"
+	EventAlertEscalated = "alert.escalated" // Sent by an escalation step
+	EventDigest         = "digest"
+	EventTest           = "test"
+)
+
+// Headers sent with outbound webhooks besides the signature headers
+// shared with inbound webhooks
+const (
+	webhookEventHeader    = "X-JustPing-Event"
+	webhookDeliveryHeader = "X-JustPing-Delivery" // Stable across retries, for deduplication
+)
+
+// maxResponseBody caps how much of an endpoint's response is logged
+const maxResponseBody = 2048
+
+// webhookPayload is the body POSTed to webhook endpoints. It is signed
+// like inbound webhooks: X-JustPing-Signature is "sha256=" followed by the
+// hex HMAC-SHA256 of "<X-JustPing-Timestamp>.<body>" keyed with the
+// channel's secret.
+type webhookPayload struct {
+	Version    int            `json:"version"`
+	Event      string         `json:"event"`
+	DeliveryID string         `json:"deliveryId,omitempty"`
+	Escalation int            `json:"escalation,omitempty"` // Escalation step, from 1
+	Alert      webhookAlert   `json:"alert"`
+	Monitor    webhookMonitor `json:"monitor"`
+}
+
+type webhookAlert struct {
+	ID            string    `json:"id"`
+	Title         string    `json:"title,omitempty"`
+	Summary       string    `json:"summary"`
+	URL           string    `json:"url"`
+	Diff          string    `json:"diff,omitempty"`
+	Added         []string  `json:"added"`
+	Removed       []string  `json:"removed"`
+	TriggeredText string    `json:"triggeredText,omitempty"`
+	Count         int       `json:"count"`
+	ReceivedAt    time.Time `json:"receivedAt"`
+	Link          string    `json:"link"`
+}
+
+type webhookMonitor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// webhookDigestPayload is the body of digest webhooks, signed the same way
+type webhookDigestPayload struct {
+	Version     int                    `json:"version"`
+	Event       string                 `json:"event"`
+	DeliveryID  string                 `json:"deliveryId"`
+	Frequency   string                 `json:"frequency"`
+	PeriodStart time.Time              `json:"periodStart"`
+	PeriodEnd   time.Time              `json:"periodEnd"`
+	Changes     int                    `json:"changes"`
+	Monitors    []webhookDigestMonitor `json:"monitors"`
+}
+
+type webhookDigestMonitor struct {
+	webhookMonitor
+	Changes int            `json:"changes"`
+	Alerts  []webhookAlert `json:"alerts"`
+}
+
+// webhookResponse is what an endpoint answered to one attempt
+type webhookResponse struct {
+	StatusCode int
+	Body       string
+}
+
+// statusError is returned when an endpoint answers with a non-2xx status
+type statusError struct {
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("endpoint returned %d", e.StatusCode)
+}
+
+// permanent reports whether retrying cannot help: the endpoint rejected
+// the request itself rather than failing to handle it, or redirected it,
+// which webhooks do not follow
+func (e *statusError) permanent() bool {
+	if e.StatusCode >= 300 && e.StatusCode < 400 {
+		return true
+	}
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+// webhookNotifier POSTs signed alert payloads to users' endpoints. Its
+// client refuses internal addresses and does not follow redirects.
+type webhookNotifier struct {
+	client *http.Client
+}
+
+func newWebhookNotifier() *webhookNotifier {
+	return &webhookNotifier{client: safehttp.NewClient(15*time.Second, false)}
+}
+
+func (wn *webhookNotifier) Name() string {
+	return ChannelWebhook
+}
+
+func (wn *webhookNotifier) Send(ctx context.Context, n Notification) error {
+	return sendToUserChannels(ctx, wn, ChannelWebhook, n)
+}
+
+func (wn *webhookNotifier) sendTo(ctx context.Context, channel models.Channel, n Notification) error {
+	deliveryID := ""
+	if n.Test {
+		deliveryID = "test"
+	}
+	_, err := wn.post(ctx, channel, n, deliveryID)
+	return err
+}
+
+func (wn *webhookNotifier) sendDigestTo(ctx context.Context, channel models.Channel, d DigestNotification) error {
+	payload := webhookDigestPayload{
+		Version:     WebhookPayloadVersion,
+		Event:       EventDigest,
+		DeliveryID:  d.ID,
+		Frequency:   d.Frequency,
+		PeriodStart: d.PeriodStart,
+		PeriodEnd:   d.PeriodEnd,
+		Changes:     d.Changes(),
+		Monitors:    make([]webhookDigestMonitor, 0, len(d.Monitors)),
+	}
+	for _, m := range d.Monitors {
+		entry := webhookDigestMonitor{Changes: m.Changes, Alerts: make([]webhookAlert, 0, len(m.Alerts))}
+		for _, alert := range m.Alerts {
+			p := newWebhookPayload(Notification{Alert: alert, Monitor: m.Monitor}, EventDigest, d.ID)
+			entry.webhookMonitor = p.Monitor
+			entry.Alerts = append(entry.Alerts, p.Alert)
+		}
+		payload.Monitors = append(payload.Monitors, entry)
+	}
+
+	_, err := wn.postEvent(ctx, channel, EventDigest, d.ID, payload)
+	return err
+}
+
+// post sends one signed alert payload; see postEvent
+func (wn *webhookNotifier) post(ctx context.Context, channel models.Channel, n Notification, deliveryID string) (*webhookResponse, error) {
+	event := EventAlertCreated
+	switch {
+	case n.Test:
+		event = EventTest
+	case n.Escalation > 0:
+		event = EventAlertEscalated
+	}
+	return wn.postEvent(ctx, channel, event, deliveryID, newWebhookPayload(n, event, deliveryID))
+}
+
+// postEvent sends one signed payload and returns the endpoint's response,
+// which is also set when the endpoint answered with an error status
+func (wn *webhookNotifier) postEvent(ctx context.Context, channel models.Channel, event, deliveryID string, payload any) (*webhookResponse, error) {
+	if channel.Secret == "" {
+		return nil, fmt.Errorf("webhook has no signing secret")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("render webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("JustPing-Webhook/%d", WebhookPayloadVersion))
+	req.Header.Set(auth.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(auth.WebhookSignatureHeader, auth.SignWebhook(channel.Secret, timestamp, body))
+	req.Header.Set(webhookEventHeader, event)
+	if deliveryID != "" {
+		req.Header.Set(webhookDeliveryHeader, deliveryID)
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody+1))
+	result := &webhookResponse{
+		StatusCode: resp.StatusCode,
+		Body:       truncate(string(respBody), maxResponseBody),
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, &statusError{StatusCode: resp.StatusCode}
+	}
+	return result, nil
+}
+
+func newWebhookPayload(n Notification, event, deliveryID string) webhookPayload {
+	added, removed := n.Alert.Added, n.Alert.Removed
+	if added == nil {
+		added = []string{}
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+
+	return webhookPayload{
+		Version:    WebhookPayloadVersion,
+		Event:      event,
+		DeliveryID: deliveryID,
+		Escalation: n.Escalation,
+		Alert: webhookAlert{
+			ID:            n.Alert.ID.Hex(),
+			Title:         n.Alert.Title,
+			Summary:       summary(n.Alert),
+			URL:           pageURL(n),
+			Diff:          n.Alert.Diff,
+			Added:         added,
+			Removed:       removed,
+			TriggeredText: n.Alert.TriggeredText,
+			Count:         max(n.Alert.Count, 1),
+			ReceivedAt:    n.Alert.ReceivedAt,
+			Link:          alertLink(n.Alert),
+		},
+		Monitor: webhookMonitor{
+			ID:   n.Monitor.ID.Hex(),
+			Name: monitorName(n),
+			URL:  n.Monitor.URL,
+		},
+	}
+}
+
+// validateEndpoint checks the URL of a user's webhook endpoint. Outside
+// development it must be https and must not name an internal host; names
+// that resolve to one are refused when connecting.
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an http or https URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("webhook URL must not contain credentials")
+	}
+	if safehttp.Development() {
+		return nil
+	}
+
+	if u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must be an https URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("webhook URL must not point at an internal address")
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !safehttp.PublicAddr(ip) {
+		return fmt.Errorf("webhook URL must not point at an internal address")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"justping/backend/internal/safehttp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ErrNoMatch is returned when a selector matches no element on the page.
+var ErrNoMatch = errors.New("selector matched no elements")
+
+// renderClient loads the requests of rendered pages, so the browser cannot
+// reach internal addresses either
+var renderClient = safehttp.NewClient(renderTimeout, true)
+
+// xpathPrefix marks an explicit XPath selector, as used by changedetection.io
+const xpathPrefix = "xpath:"
+
+// IsXPath reports whether selector is an XPath expression rather than CSS.
+// XPath is either prefixed with "xpath:" or starts with "/" or "(".
+func IsXPath(selector string) bool {
+	s := strings.TrimSpace(selector)
+	return strings.HasPrefix(s, xpathPrefix) || strings.HasPrefix(s, "/") || strings.HasPrefix(s, "(")
+}
+
+// SelectHTML loads html into a blank page (scripts stripped, so nothing is
+// executed) and returns the outer HTML of every element matching selector.
+// Every network request the page makes is refused, so images, stylesheets
+// or frames in the fetched HTML cannot reach other hosts.
+// The result wraps ErrNoMatch when nothing matches.
+func SelectHTML(ctx context.Context, html, selector string) ([]string, error) {
+	browser := GetBrowser()
+	if browser == nil {
+		return nil, fmt.Errorf("browser not initialised")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		return nil, fmt.Errorf("create page: %w", err)
+	}
+	defer page.Close()
+
+	page = page.Context(ctx)
+
+	router := page.HijackRequests()
+	if err := router.Add("*", "", func(h *rod.Hijack) {
+		h.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+	}); err != nil {
+		return nil, fmt.Errorf("block requests: %w", err)
+	}
+	go router.Run()
+	defer router.Stop()
+
+	if err := page.SetDocumentContent(sanitize(html)); err != nil {
+		return nil, fmt.Errorf("load html: %w", err)
+	}
+
+	return selectElements(page, selector)
+}
+
+// RenderSelection renders targetURL like RenderPage and returns the outer
+// HTML of every element matching selector in the live DOM. The page's
+// requests are made through renderClient and fail when it refuses them.
+// The result wraps ErrNoMatch when nothing matches.
+func RenderSelection(ctx context.Context, targetURL, selector string) ([]string, error) {
+	browser := GetBrowser()
+	if browser == nil {
+		return nil, fmt.Errorf("browser not initialised")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		return nil, fmt.Errorf("create page: %w", err)
+	}
+	defer page.Close()
+
+	page = page.Context(ctx)
+
+	router := page.HijackRequests()
+	if err := router.Add("*", "", func(h *rod.Hijack) {
+		if err := h.LoadResponse(renderClient, true); err != nil {
+			h.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("route requests: %w", err)
+	}
+	go router.Run()
+	defer router.Stop()
+
+	if err := page.Navigate(targetURL); err != nil {
+		return nil, fmt.Errorf("navigate: %w", err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return nil, fmt.Errorf("wait load: %w", err)
+	}
+	// WaitIdle: non-fatal — some SPAs never fully idle
+	_ = page.WaitIdle(500 * time.Millisecond)
+
+	return selectElements(page, selector)
+}
+
+// selectElements runs a CSS or XPath query against page
+func selectElements(page *rod.Page, selector string) ([]string, error) {
+	var elements rod.Elements
+	var err error
+	if IsXPath(selector) {
+		elements, err = page.ElementsX(strings.TrimPrefix(strings.TrimSpace(selector), xpathPrefix))
+	} else {
+		elements, err = page.Elements(selector)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("%q: %w", selector, ErrNoMatch)
+	}
+
+	matches := make([]string, 0, len(elements))
+	for _, el := range elements {
+		html, err := el.HTML()
+		if err != nil {
+			return nil, fmt.Errorf("read element: %w", err)
+		}
+		matches = append(matches, html)
+	}
+	return matches, nil
+}
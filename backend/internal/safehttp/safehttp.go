@@ -0,0 +1,99 @@
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a request would connect to an address
+// that is not publicly routable
+var ErrBlockedAddress = errors.New("connections to internal addresses are not allowed")
+
+// blockedPrefixes are non-public ranges the netip predicates do not cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, incl. broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, may embed an internal IPv4
+	netip.MustParsePrefix("2002::/16"),     // 6to4, may embed an internal IPv4
+}
+
+// Development reports whether APP_ENV is "development". Local setups may
+// then use plain http and internal addresses.
+func Development() bool {
+	return os.Getenv("APP_ENV") == "development"
+}
+
+// PublicAddr reports whether ip is a publicly routable unicast address
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient returns a client for requests to user-supplied URLs. Every
+// connection, including those for redirects, is checked at dial time
+// against the resolved address, so a hostname cannot be pointed at an
+// internal address after it was validated. Redirects are only followed when
+// followRedirects is set; otherwise the 3xx response is returned.
+// Outside development, internal addresses are refused with
+// ErrBlockedAddress.
+func NewClient(timeout time.Duration, followRedirects bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDial,
+	}
+	transport := &http.Transport{
+		// No proxy: it would make the connection, bypassing the check
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	if !followRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client
+}
+
+// checkDial runs after name resolution, right before each connection
+func checkDial(network, address string, _ syscall.RawConn) error {
+	if Development() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package safehttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewClient(5*time.Second, true).Get(server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Get(%s) error = %v, want ErrBlockedAddress", server.URL, err)
+	}
+}
+
+func TestClientAllowsInternalAddressesInDevelopment(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := NewClient(5*time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", server.URL, err)
+	}
+	resp.Body.Close()
+}
+
+func TestClientRedirects(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer server.Close()
+
+	tests := []struct {
+		followRedirects bool
+		want            int
+	}{
+		{false, http.StatusFound},
+		{true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, err := NewClient(5*time.Second, tt.followRedirects).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", server.URL, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("followRedirects=%v: status = %d, want %d", tt.followRedirects, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"justping/backend/internal/changedetection"
+	"justping/backend/internal/models"
+	"time"
+)
+
+// Names accepted by the CHECK_BACKEND setting
+const (
+	BackendChangeDetection = "changedetection"
+	BackendNative          = "native"
+)
+
+// Backend performs the periodic checks for a monitor.
+// New monitors are handed to the default backend; existing monitors stay
+// with whichever backend registered them (see BackendFor).
+type Backend interface {
+	// Name identifies the backend in logs and configuration
+	Name() string
+	// Register starts checking m. It is called before m is stored so the
+	// backend can record its own handle on the monitor.
+	Register(m *models.Monitor) error
+	// Update pushes edits to an already registered monitor. m holds the
+	// monitor as it will be stored once the update succeeds.
+	Update(m *models.Monitor) error
+	// Unregister stops checking m. Monitors already gone from the backend
+	// are not an error.
+	Unregister(m *models.Monitor) error
+	// SetPaused stops or restarts checks for m without unregistering it
+	SetPaused(m *models.Monitor, paused bool) error
+}
+
+// NewBackend returns the backend for a CHECK_BACKEND value.
+// An empty name selects changedetection.io.
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendChangeDetection:
+		return newChangeDetectionBackend(), nil
+	case BackendNative:
+		return nativeBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown check backend %q", name)
+	}
+}
+
+// BackendFor returns the backend responsible for an existing monitor.
+// Monitors with a changedetection.io watch belong to that service; all
+// others are checked by the in-process scheduler.
+func BackendFor(m *models.Monitor) Backend {
+	if m.ChangeDetectionUUID != "" {
+		return newChangeDetectionBackend()
+	}
+	return nativeBackend{}
+}
+
+// nativeBackend checks monitors from the in-process scheduler loop.
+// Registration is a no-op: the loop picks up any stored monitor without a
+// changedetection.io watch.
+type nativeBackend struct{}
+
+func (nativeBackend) Name() string { return BackendNative }
+
+// Register leaves LastChecked unset so the loop records a baseline on its
+// next tick.
+func (nativeBackend) Register(m *models.Monitor) error {
+	m.LastChecked = time.Time{}
+	return nil
+}
+
+func (nativeBackend) Update(m *models.Monitor) error { return nil }
+
+func (nativeBackend) Unregister(m *models.Monitor) error { return nil }
+
+// SetPaused is a no-op: the loop skips monitors whose status is paused.
+func (nativeBackend) SetPaused(m *models.Monitor, paused bool) error { return nil }
+
+// changeDetectionBackend delegates checks to a changedetection.io watch.
+type changeDetectionBackend struct {
+	client *changedetection.Client
+}
+
+func newChangeDetectionBackend() *changeDetectionBackend {
+	return &changeDetectionBackend{client: changedetection.NewClientFromEnv()}
+}
+
+func (b *changeDetectionBackend) Name() string { return BackendChangeDetection }
+
+func (b *changeDetectionBackend) Register(m *models.Monitor) error {
+	notificationURLs, err := changedetection.WebhookNotification(m.WebhookToken)
+	if err != nil {
+		return err
+	}
+
+	watchReq := changedetection.CreateWatchRequest{
+		URL:               m.URL,
+		Title:             m.WebsiteName,
+		TimeBetweenCheck:  changedetection.MapFrequencyToTimeBetweenCheck(m.Frequency),
+		NotificationMuted: !m.AlertsEnabled,
+		IncludeFilters:    changedetection.MapSelectorToIncludeFilters(m.Selector),
+	}
+	if notificationURLs != nil {
+		watchReq.NotificationURLs = notificationURLs
+		watchReq.NotificationBody = changedetection.NotificationBody
+		watchReq.NotificationFormat = "text"
+	}
+
+	watchUUID, err := b.client.CreateWatch(watchReq)
+	if err != nil {
+		return err
+	}
+
+	m.ChangeDetectionUUID = watchUUID
+	return nil
+}
+
+func (b *changeDetectionBackend) Update(m *models.Monitor) error {
+	notificationURLs, err := changedetection.WebhookNotification(m.WebhookToken)
+	if err != nil {
+		return err
+	}
+
+	// Filters and notifications are always sent, empty ones included, so
+	// the watch matches m even when m has no selector or webhook (e.g. when
+	// rolling back a failed update)
+	includeFilters := changedetection.MapSelectorToIncludeFilters(m.Selector)
+	if includeFilters == nil {
+		includeFilters = []string{}
+	}
+	notificationBody, notificationFormat := "", "text"
+	if notificationURLs == nil {
+		notificationURLs = []string{}
+	} else {
+		notificationBody = changedetection.NotificationBody
+	}
+
+	watchReq := changedetection.UpdateWatchRequest{
+		URL:                m.URL,
+		Title:              m.WebsiteName,
+		TimeBetweenCheck:   changedetection.MapFrequencyToTimeBetweenCheck(m.Frequency),
+		IncludeFilters:     &includeFilters,
+		NotificationURLs:   &notificationURLs,
+		NotificationBody:   &notificationBody,
+		NotificationFormat: &notificationFormat,
+	}
+	return b.client.UpdateWatch(m.ChangeDetectionUUID, watchReq)
+}
+
+func (b *changeDetectionBackend) Unregister(m *models.Monitor) error {
+	err := b.client.DeleteWatch(m.ChangeDetectionUUID)
+	if errors.Is(err, changedetection.ErrWatchNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (b *changeDetectionBackend) SetPaused(m *models.Monitor, paused bool) error {
+	return b.client.SetPaused(m.ChangeDetectionUUID, paused)
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Values of models.Duration.Type
+const (
+	DurationForever     = "forever"
+	DurationUntilDate   = "until_date"
+	DurationFirstChange = "first_change"
+)
+
+// DurationEnd returns when an until_date monitor stops. EndDate is either a
+// calendar date (YYYY-MM-DD), in which case the monitor runs through the end
+// of that day UTC, or an RFC 3339 timestamp.
+func DurationEnd(d *models.Duration) (time.Time, bool) {
+	if d == nil || d.Type != DurationUntilDate || d.EndDate == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse("2006-01-02", d.EndDate); err == nil {
+		return t.AddDate(0, 0, 1), true
+	}
+	if t, err := time.Parse(time.RFC3339, d.EndDate); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// ValidateDuration checks a duration from a request, so the scheduler never
+// sees an until_date monitor without a usable end date
+func ValidateDuration(d *models.Duration) error {
+	switch d.Type {
+	case DurationForever, DurationFirstChange:
+		return nil
+	case DurationUntilDate:
+		if _, ok := DurationEnd(d); !ok {
+			return fmt.Errorf("endDate must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		}
+		return nil
+	default:
+		return fmt.Errorf("duration type must be forever, until_date or first_change")
+	}
+}
+
+// DurationEnded reports whether an until_date monitor's end date has passed
+// at now. Such a monitor needs a new end date before it can be resumed.
+func DurationEnded(d *models.Duration, now time.Time) bool {
+	end, ok := DurationEnd(d)
+	return ok && !now.Before(end)
+}
+
+// StopAfterChange pauses a first_change monitor once a change was alerted.
+// It is a no-op for other durations.
+func StopAfterChange(ctx context.Context, m *models.Monitor) {
+	if m.Duration == nil || m.Duration.Type != DurationFirstChange {
+		return
+	}
+	if err := SetPaused(ctx, m, true, StopReasonFirstChange); err != nil {
+		log.Printf("[scheduler] Failed to stop first_change monitor %s: %v", m.ID.Hex(), err)
+	}
+}
+
+// enforceDurations pauses until_date monitors whose end date has passed.
+// Monitors without a usable end date, which ValidateDuration keeps out,
+// are left running.
+func enforceDurations() {
+	collection := database.GetMonitorsCollection()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"duration.type": DurationUntilDate,
+		"status":        bson.M{"$ne": "paused"},
+	})
+	if err != nil {
+		log.Printf("[scheduler] Database error: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		log.Printf("[scheduler] Cursor error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range monitors {
+		m := &monitors[i]
+		end, ok := DurationEnd(m.Duration)
+		if !ok || now.Before(end) {
+			continue
+		}
+		if err := SetPaused(ctx, m, true, StopReasonEndDate); err != nil {
+			log.Printf("[scheduler] Failed to stop monitor %s after end date: %v", m.ID.Hex(), err)
+		}
+	}
+}
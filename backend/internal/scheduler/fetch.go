@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"justping/backend/internal/renderer"
+	"justping/backend/internal/safehttp"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	fetchUserAgent = "JustPing/1.0 (+https://github.com/dipsubhro/justping)"
+	// maxBodySize caps how much of a page is read into memory
+	maxBodySize = 5 << 20
+)
+
+// compiled text-extraction patterns (once at package init)
+var (
+	// Drop blocks whose contents are never visible text
+	reInvisibleBlock = regexp.MustCompile(`(?si)<(script|style|noscript|template)\b[^>]*>.*?</(script|style|noscript|template)>`)
+	// Drop HTML comments
+	reComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// Block-level tags become line breaks so text keeps its structure
+	reBlockTag = regexp.MustCompile(`(?i)</?(p|div|br|li|tr|h[1-6]|section|article|header|footer|table|ul|ol)\b[^>]*>`)
+	// Any remaining tag is removed
+	reAnyTag = regexp.MustCompile(`(?s)<[^>]+>`)
+	// Runs of horizontal whitespace collapse to one space
+	reSpaces = regexp.MustCompile(`[ \t\f\r\v]+`)
+)
+
+// FetchResult is what a single check saw at the monitored URL.
+type FetchResult struct {
+	StatusCode int
+	Headers    http.Header
+	Body       string        // raw response body
+	Text       string        // visible text used for change detection
+	Hash       string        // sha256 of Text
+	Duration   time.Duration // time taken to fetch the page
+}
+
+// httpClient follows redirects but, like webhooks, refuses to connect to
+// internal addresses, so monitors cannot be used to probe the host network
+var httpClient = safehttp.NewClient(30*time.Second, true)
+
+// Fetch downloads targetURL and extracts its visible text.
+// Non-2xx responses are returned as errors together with the result, so
+// callers can still record what the server sent back.
+func Fetch(ctx context.Context, targetURL string) (*FetchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", fetchUserAgent)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	text := htmlToText(string(body))
+	result := &FetchResult{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		Body:       string(body),
+		Text:       text,
+		Hash:       hashText(text),
+		Duration:   time.Since(start),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return result, nil
+}
+
+// ApplySelector narrows result to the elements matching selector, so only
+// they are compared between checks. The fetched HTML is tried first; if
+// nothing matches there the page is rendered, since selectors picked in the
+// UI come from the rendered DOM. Both steps are bounded by ctx.
+func ApplySelector(ctx context.Context, result *FetchResult, targetURL, selector string) error {
+	matches, err := renderer.SelectHTML(ctx, result.Body, selector)
+	if errors.Is(err, renderer.ErrNoMatch) {
+		matches, err = renderer.RenderSelection(ctx, targetURL, selector)
+	}
+	if errors.Is(err, renderer.ErrNoMatch) {
+		return fmt.Errorf("selector %q no longer matches any element on the page", selector)
+	}
+	if err != nil {
+		return fmt.Errorf("apply selector: %w", err)
+	}
+
+	result.Text = htmlToText(strings.Join(matches, "\n"))
+	result.Hash = hashText(result.Text)
+	return nil
+}
+
+// htmlToText strips markup and returns the visible text, one block per line.
+func htmlToText(s string) string {
+	s = reInvisibleBlock.ReplaceAllString(s, "")
+	s = reComment.ReplaceAllString(s, "")
+	s = reBlockTag.ReplaceAllString(s, "\n")
+	s = reAnyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(reSpaces.ReplaceAllString(line, " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hashText(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Reasons recorded on monitors stopped automatically
+const (
+	StopReasonEndDate     = "end_date_reached"
+	StopReasonFirstChange = "first_change_detected"
+)
+
+// BackendError marks failures reported by the check backend, as opposed to
+// local database errors
+type BackendError struct {
+	Err error
+}
+
+func (e BackendError) Error() string {
+	return "change detection service: " + e.Err.Error()
+}
+
+func (e BackendError) Unwrap() error { return e.Err }
+
+// SetPaused flips a monitor's paused state on its check backend and then
+// locally. If the local write fails the backend is flipped back. reason is
+// recorded when pausing automatically and cleared on resume; pass "" for a
+// manual pause. m is updated in place on success.
+func SetPaused(ctx context.Context, m *models.Monitor, paused bool, reason string) error {
+	status := "active"
+	if paused {
+		status = "paused"
+	}
+	if m.Status == status {
+		return nil
+	}
+
+	backend := BackendFor(m)
+	if err := backend.SetPaused(m, paused); err != nil {
+		return BackendError{err}
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":    status,
+			"updatedAt": now,
+		},
+	}
+	if paused && reason != "" {
+		update["$set"].(bson.M)["stoppedReason"] = reason
+		update["$set"].(bson.M)["stoppedAt"] = now
+	} else {
+		update["$unset"] = bson.M{"stoppedReason": "", "stoppedAt": ""}
+	}
+
+	_, err := database.GetMonitorsCollection().UpdateOne(ctx, bson.M{"_id": m.ID, "userId": m.UserID}, update)
+	if err != nil {
+		if rollbackErr := backend.SetPaused(m, !paused); rollbackErr != nil {
+			log.Printf("[scheduler] Backend (%s) rollback failed for monitor %s: %v", backend.Name(), m.ID.Hex(), rollbackErr)
+		}
+		return fmt.Errorf("database: %w", err)
+	}
+
+	if paused {
+		log.Printf("[scheduler] Monitor %s paused%s", m.ID.Hex(), formatReason(reason))
+	} else {
+		log.Printf("[scheduler] Monitor %s resumed", m.ID.Hex())
+	}
+
+	m.Status = status
+	m.UpdatedAt = now
+	if paused && reason != "" {
+		m.StoppedReason = reason
+		m.StoppedAt = &now
+	} else {
+		m.StoppedReason = ""
+		m.StoppedAt = nil
+	}
+	return nil
+}
+
+func formatReason(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return " (" + reason + ")"
+}
@@ -0,0 +1,383 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"justping/backend/internal/changedetection"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// orphanGracePeriod is how long a watch must stay unreferenced before it is
+// deleted. CreateMonitor registers the watch before the monitor is stored,
+// so a brand-new watch briefly looks orphaned.
+const orphanGracePeriod = 15 * time.Minute
+
+// Kinds of discrepancy found by Reconcile
+const (
+	IssueOrphanWatch    = "orphan_watch"    // watch without a monitor
+	IssueMissingWatch   = "missing_watch"   // monitor whose watch is gone
+	IssuePausedMismatch = "paused_mismatch" // paused on one side only
+	IssueURLMismatch    = "url_mismatch"    // watch checks a different URL
+)
+
+// Actions taken for an issue
+const (
+	ActionNone      = "none"      // dry run, nothing changed
+	ActionPending   = "pending"   // orphan still inside the grace period
+	ActionSkipped   = "skipped"   // orphan kept, deletion is not enabled
+	ActionDeleted   = "deleted"   // orphan watch removed
+	ActionRecreated = "recreated" // watch created again for the monitor
+	ActionUpdated   = "updated"   // watch changed to match the monitor
+	ActionFailed    = "failed"    // repair attempted but failed, see Error
+)
+
+// ReconcileIssue is one discrepancy between Mongo and changedetection.io
+type ReconcileIssue struct {
+	Kind      string `json:"kind"`
+	WatchUUID string `json:"watchUuid"`
+	MonitorID string `json:"monitorId,omitempty"`
+	Action    string `json:"action"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReconcileReport summarises one reconciliation run
+type ReconcileReport struct {
+	DryRun        bool             `json:"dryRun"`
+	DeleteOrphans bool             `json:"deleteOrphans"`
+	ClaimLegacy   bool             `json:"claimLegacy"`
+	StartedAt     time.Time        `json:"startedAt"`
+	RemoteWatches int              `json:"remoteWatches"`
+	Monitors      int              `json:"monitors"`
+	Issues        []ReconcileIssue `json:"issues"`
+}
+
+// orphanSuspect is a watch seen without a monitor
+type orphanSuspect struct {
+	firstSeen time.Time
+	owned     bool // JustPing created it, see ownsWatch
+}
+
+var (
+	// orphanSuspects maps watch UUIDs to when they were first seen without
+	// a monitor. Guarded by reconcileMu.
+	orphanSuspects = map[string]orphanSuspect{}
+	reconcileMu    sync.Mutex
+)
+
+// StartReconciler periodically reconciles monitors with changedetection.io
+// watches. Call after Start; an interval <= 0 disables it, and it does not
+// run when neither the default backend nor any monitor uses
+// changedetection.io. Orphaned watches are only deleted when deleteOrphans
+// is set.
+func StartReconciler(interval time.Duration, deleteOrphans bool) {
+	if interval <= 0 {
+		log.Println("[scheduler] Reconciler disabled")
+		return
+	}
+	if GetBackend().Name() != BackendChangeDetection {
+		inUse, err := changeDetectionInUse()
+		if err != nil {
+			log.Printf("[scheduler] Could not look for changedetection.io monitors, starting reconciler: %v", err)
+		} else if !inUse {
+			log.Println("[scheduler] Reconciler not started: no monitor uses changedetection.io")
+			return
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				report, err := Reconcile(false, deleteOrphans, false)
+				if err != nil {
+					log.Printf("[scheduler] Reconcile failed: %v", err)
+					continue
+				}
+				if len(report.Issues) > 0 {
+					log.Printf("[scheduler] Reconcile found %d issues", len(report.Issues))
+				}
+			}
+		}
+	}()
+
+	log.Printf("[scheduler] Reconciler every %s (delete orphans: %t)", interval, deleteOrphans)
+}
+
+// changeDetectionInUse reports whether any monitor is checked by
+// changedetection.io. With the native default backend no new ones appear,
+// so the answer at startup holds.
+func changeDetectionInUse() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := database.GetMonitorsCollection().CountDocuments(ctx, bson.M{
+		"changeDetectionUuid": bson.M{"$nin": bson.A{nil, ""}},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Reconcile compares the monitors collection with the watches on
+// changedetection.io and repairs the differences, or only reports them
+// when dryRun is set:
+//   - watches without a monitor are deleted once past orphanGracePeriod, if
+//     deleteOrphans is set and JustPing created the watch; other watches
+//     are never touched
+//   - monitors whose watch is gone get a new watch
+//   - paused state and URL of each watch are brought in line with the monitor
+//
+// claimLegacy is a one-time migration for watches orphaned before watches
+// were given our webhook URL: it also treats orphans without any
+// notification URL as JustPing's. Only use it when no one else creates
+// watches on the changedetection.io instance.
+func Reconcile(dryRun, deleteOrphans, claimLegacy bool) (*ReconcileReport, error) {
+	reconcileMu.Lock()
+	defer reconcileMu.Unlock()
+
+	report := &ReconcileReport{DryRun: dryRun, DeleteOrphans: deleteOrphans, ClaimLegacy: claimLegacy, StartedAt: time.Now(), Issues: []ReconcileIssue{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Monitors are read before the watches: a monitor created in between
+	// then only makes its new watch look orphaned for a while, instead of
+	// looking like a monitor whose watch is missing
+	cursor, err := database.GetMonitorsCollection().Find(ctx, bson.M{
+		"changeDetectionUuid": bson.M{"$nin": bson.A{nil, ""}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		return nil, err
+	}
+	report.Monitors = len(monitors)
+
+	client := changedetection.NewClientFromEnv()
+	watches, err := client.ListWatches()
+	if err != nil {
+		return nil, err
+	}
+	report.RemoteWatches = len(watches)
+
+	backend := &changeDetectionBackend{client: client}
+	known := make(map[string]bool, len(monitors))
+	for i := range monitors {
+		m := &monitors[i]
+		known[m.ChangeDetectionUUID] = true
+
+		watch, ok := watches[m.ChangeDetectionUUID]
+		if !ok {
+			report.add(IssueMissingWatch, m.ChangeDetectionUUID, m, dryRun, func() (string, error) {
+				return ActionRecreated, recreateWatch(ctx, backend, m)
+			})
+			continue
+		}
+
+		paused := m.Status == "paused"
+		if watch.Paused != paused {
+			report.add(IssuePausedMismatch, watch.UUID, m, dryRun, func() (string, error) {
+				return ActionUpdated, client.SetPaused(watch.UUID, paused)
+			})
+		}
+		if watch.URL != "" && watch.URL != m.URL {
+			report.add(IssueURLMismatch, watch.UUID, m, dryRun, func() (string, error) {
+				return ActionUpdated, backend.Update(m)
+			})
+		}
+	}
+
+	var unknown []string
+	for uuid := range watches {
+		if !known[uuid] {
+			if suspect, seen := orphanSuspects[uuid]; !seen || (claimLegacy && !suspect.owned) {
+				unknown = append(unknown, uuid)
+			}
+		}
+	}
+	alerted, err := alertedWatches(ctx, unknown)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for uuid := range watches {
+		if known[uuid] {
+			delete(orphanSuspects, uuid)
+			continue
+		}
+
+		suspect, seen := orphanSuspects[uuid]
+		if !seen || (claimLegacy && !suspect.owned) {
+			owned, err := ownsWatch(client, uuid, alerted[uuid], claimLegacy)
+			if err != nil {
+				log.Printf("[scheduler] Reconcile could not inspect watch %s: %v", uuid, err)
+				continue
+			}
+			if !seen {
+				suspect.firstSeen = now
+			}
+			suspect.owned = owned
+			if !dryRun {
+				orphanSuspects[uuid] = suspect
+			}
+		}
+		// Someone else's watch on a shared changedetection.io
+		if !suspect.owned {
+			continue
+		}
+
+		action := ""
+		switch {
+		case dryRun:
+			action = ActionNone
+		case !deleteOrphans:
+			action = ActionSkipped
+		case !seen || now.Sub(suspect.firstSeen) < orphanGracePeriod:
+			action = ActionPending
+		}
+		if action != "" {
+			report.Issues = append(report.Issues, ReconcileIssue{Kind: IssueOrphanWatch, WatchUUID: uuid, Action: action})
+			continue
+		}
+
+		report.add(IssueOrphanWatch, uuid, nil, dryRun, func() (string, error) {
+			err := client.DeleteWatch(uuid)
+			if errors.Is(err, changedetection.ErrWatchNotFound) {
+				err = nil
+			}
+			if err == nil {
+				delete(orphanSuspects, uuid)
+			}
+			return ActionDeleted, err
+		})
+	}
+
+	// Forget suspects that disappeared on their own
+	if !dryRun {
+		for uuid := range orphanSuspects {
+			if _, ok := watches[uuid]; !ok {
+				delete(orphanSuspects, uuid)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// ownsWatch reports whether JustPing created a watch: it notifies this
+// backend's webhook, it sent us alerts before (watches of monitors deleted
+// before deletes removed them), or, with claimLegacy, it has no
+// notification URL at all like watches created before webhook URLs. A
+// watch that is already gone is not owned.
+func ownsWatch(client *changedetection.Client, uuid string, alerted, claimLegacy bool) (bool, error) {
+	watch, err := client.GetWatch(uuid)
+	if errors.Is(err, changedetection.ErrWatchNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, u := range watch.NotificationURLs {
+		if changedetection.IsWebhookNotificationURL(u) {
+			return true, nil
+		}
+	}
+	return alerted || (claimLegacy && len(watch.NotificationURLs) == 0), nil
+}
+
+// alertedWatches returns which of uuids have alerts stored for them. The
+// payload is what changedetection.io sent, so it names the watch even
+// when the monitor is gone.
+func alertedWatches(ctx context.Context, uuids []string) (map[string]bool, error) {
+	alerted := map[string]bool{}
+	if len(uuids) == 0 {
+		return alerted, nil
+	}
+
+	values, err := database.GetAlertsCollection().Distinct(ctx, "payload.watch_uuid", bson.M{
+		"payload.watch_uuid": bson.M{"$in": uuids},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		if uuid, ok := v.(string); ok {
+			alerted[uuid] = true
+		}
+	}
+	return alerted, nil
+}
+
+// add records an issue and, unless dryRun, runs repair for it
+func (r *ReconcileReport) add(kind, watchUUID string, m *models.Monitor, dryRun bool, repair func() (string, error)) {
+	issue := ReconcileIssue{Kind: kind, WatchUUID: watchUUID, Action: ActionNone}
+	if m != nil {
+		issue.MonitorID = m.ID.Hex()
+	}
+
+	if !dryRun {
+		action, err := repair()
+		issue.Action = action
+		if err != nil {
+			issue.Action = ActionFailed
+			issue.Error = err.Error()
+			log.Printf("[scheduler] Reconcile %s for watch %s failed: %v", kind, watchUUID, err)
+		} else {
+			log.Printf("[scheduler] Reconcile %s for watch %s: %s", kind, watchUUID, action)
+		}
+	}
+
+	r.Issues = append(r.Issues, issue)
+}
+
+// recreateWatch registers a new watch for a monitor whose watch is gone and
+// stores its UUID. The new watch is removed again if the monitor cannot be
+// updated, so no orphan is left behind.
+func recreateWatch(ctx context.Context, backend *changeDetectionBackend, m *models.Monitor) error {
+	fresh := *m
+	fresh.ChangeDetectionUUID = ""
+	if err := backend.Register(&fresh); err != nil {
+		return err
+	}
+
+	if m.Status == "paused" {
+		if err := backend.SetPaused(&fresh, true); err != nil {
+			backend.Unregister(&fresh)
+			return err
+		}
+	}
+
+	res, err := database.GetMonitorsCollection().UpdateOne(ctx,
+		bson.M{"_id": m.ID, "changeDetectionUuid": m.ChangeDetectionUUID},
+		bson.M{"$set": bson.M{"changeDetectionUuid": fresh.ChangeDetectionUUID, "updatedAt": time.Now()}},
+	)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("monitor was changed or deleted during reconcile")
+	}
+	if err != nil {
+		backend.Unregister(&fresh)
+		return err
+	}
+
+	m.ChangeDetectionUUID = fresh.ChangeDetectionUUID
+	return nil
+}
@@ -0,0 +1,409 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"justping/backend/internal/alerting"
+	"justping/backend/internal/changedetection"
+	"justping/backend/internal/database"
+	"justping/backend/internal/diff"
+	"justping/backend/internal/models"
+	"justping/backend/internal/notifier"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// tickInterval is how often the loop looks for monitors that are due
+	tickInterval = 30 * time.Second
+	// checkTimeout bounds a single fetch, its selector renders and its
+	// database writes
+	checkTimeout = 75 * time.Second
+	// maxConcurrentChecks limits how many pages are fetched at once
+	maxConcurrentChecks = 5
+	// maxSnapshots is how many snapshots are kept per monitor
+	maxSnapshots = 100
+)
+
+var (
+	defaultBackend Backend
+	stopCh         chan struct{}
+	wg             sync.WaitGroup
+	// inFlight holds the IDs of monitors currently being checked so a slow
+	// check is not started again on the next tick
+	inFlight sync.Map
+)
+
+// Start selects the backend used for new monitors and launches the native
+// check loop. Call once at startup; defer Stop for cleanup.
+//
+// Selecting changedetection.io requires PUBLIC_BASE_URL.
+//
+// The loop runs regardless of the selected backend so monitors created
+// natively keep being checked after switching back to changedetection.io.
+func Start(backendName string) error {
+	backend, err := NewBackend(backendName)
+	if err != nil {
+		return err
+	}
+	// Watches created without a webhook URL would never deliver alerts
+	if backend.Name() == BackendChangeDetection {
+		if err := changedetection.CheckPublicBaseURL(); err != nil {
+			return err
+		}
+	}
+	defaultBackend = backend
+
+	stopCh = make(chan struct{})
+	wg.Add(1)
+	go loop()
+
+	log.Printf("[scheduler] Started (default backend: %s)", backend.Name())
+	return nil
+}
+
+// GetBackend returns the backend new monitors are registered with.
+func GetBackend() Backend {
+	if defaultBackend == nil {
+		return newChangeDetectionBackend()
+	}
+	return defaultBackend
+}
+
+// Stop halts the loop and waits for in-flight checks to finish.
+func Stop() {
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	wg.Wait()
+	log.Println("[scheduler] Stopped")
+}
+
+func loop() {
+	defer wg.Done()
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	sem := make(chan struct{}, maxConcurrentChecks)
+	enforceDurations()
+	runDue(sem)
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			enforceDurations()
+			runDue(sem)
+		}
+	}
+}
+
+// runDue starts a check for every native monitor whose interval has elapsed.
+func runDue(sem chan struct{}) {
+	collection := database.GetMonitorsCollection()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"changeDetectionUuid": bson.M{"$in": bson.A{nil, ""}},
+		"status":              bson.M{"$in": bson.A{"active", "error"}},
+	})
+	if err != nil {
+		log.Printf("[scheduler] Database error: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		log.Printf("[scheduler] Cursor error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, m := range monitors {
+		if !isDue(m, now) {
+			continue
+		}
+		if _, busy := inFlight.LoadOrStore(m.ID, struct{}{}); busy {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-stopCh:
+			inFlight.Delete(m.ID)
+			return
+		}
+
+		wg.Add(1)
+		go func(m models.Monitor) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer inFlight.Delete(m.ID)
+			check(m)
+		}(m)
+	}
+}
+
+// maxFailureBackoff caps how far failed checks stretch a monitor's interval
+const maxFailureBackoff = 24 * time.Hour
+
+// isDue reports whether m should be checked at now. A monitor that has
+// never been checked is checked straight away to record its baseline.
+func isDue(m models.Monitor, now time.Time) bool {
+	if m.LastChecked.IsZero() {
+		return true
+	}
+	return !now.Before(m.LastChecked.Add(checkInterval(m)))
+}
+
+// checkInterval is the monitor's frequency, doubled for every consecutive
+// failed check after the first, up to maxFailureBackoff
+func checkInterval(m models.Monitor) time.Duration {
+	interval := FrequencyToDuration(m.Frequency)
+	for i := 1; i < m.CheckFailures && interval < maxFailureBackoff; i++ {
+		interval = min(interval*2, maxFailureBackoff)
+	}
+	return interval
+}
+
+// FrequencyToDuration converts our Frequency model to a check interval.
+// Unknown units fall back to minutes.
+func FrequencyToDuration(freq models.Frequency) time.Duration {
+	value := time.Duration(freq.Value)
+	if value <= 0 {
+		value = 5
+	}
+
+	switch freq.Unit {
+	case "hours":
+		return value * time.Hour
+	case "days":
+		return value * 24 * time.Hour
+	default:
+		return value * time.Minute
+	}
+}
+
+// check fetches a monitor's page, compares it with the previous check and
+// records the outcome on the monitor document.
+func check(m models.Monitor) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	result, err := Fetch(ctx, m.URL)
+	if err == nil && m.Selector != "" {
+		err = ApplySelector(ctx, result, m.URL, m.Selector)
+	}
+	now := time.Now()
+	saveSnapshot(ctx, m, result, err, now)
+
+	update := bson.M{
+		"$set": bson.M{
+			"lastChecked": now,
+		},
+	}
+
+	changed := false
+	if err != nil {
+		log.Printf("[scheduler] Check failed for monitor %s (%s): %v", m.ID.Hex(), m.URL, err)
+		update["$set"].(bson.M)["status"] = "error"
+		update["$set"].(bson.M)["lastError"] = err.Error()
+		update["$inc"] = bson.M{"checkFailures": 1}
+	} else {
+		changed = m.ContentHash != "" && result.Hash != m.ContentHash
+		update["$set"].(bson.M)["status"] = "active"
+		update["$set"].(bson.M)["contentHash"] = result.Hash
+		update["$set"].(bson.M)["hasChanged"] = changed
+		update["$unset"] = bson.M{"lastError": "", "checkFailures": ""}
+	}
+
+	// Only touch monitors that are still scheduled, so a pause issued while
+	// the fetch was running is not overwritten
+	collection := database.GetMonitorsCollection()
+	res, err := collection.UpdateOne(ctx, bson.M{
+		"_id":    m.ID,
+		"status": bson.M{"$in": bson.A{"active", "error"}},
+	}, update)
+	if err != nil {
+		log.Printf("[scheduler] Failed to update monitor %s: %v", m.ID.Hex(), err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		return
+	}
+
+	if changed {
+		log.Printf("[scheduler] Change detected for monitor %s (%s)", m.ID.Hex(), m.URL)
+		if m.AlertsEnabled {
+			createAlert(ctx, m, result, now)
+		}
+		StopAfterChange(ctx, &m)
+	}
+}
+
+// saveSnapshot records the outcome of a check in the snapshots collection.
+// Every check is stored, changed or not, so the history shows each status
+// code and fetch time. result may be nil when the page could not be fetched
+// at all.
+func saveSnapshot(ctx context.Context, m models.Monitor, result *FetchResult, fetchErr error, now time.Time) {
+	snapshot := models.Snapshot{
+		ID:        primitive.NewObjectID(),
+		UserID:    m.UserID,
+		MonitorID: m.ID,
+		Timestamp: now.Unix(),
+		CheckedAt: now,
+		Source:    BackendNative,
+	}
+	if result != nil {
+		snapshot.Content = result.Text
+		snapshot.ContentHash = result.Hash
+		snapshot.StatusCode = result.StatusCode
+		snapshot.DurationMs = result.Duration.Milliseconds()
+		snapshot.Headers = make(map[string]string, len(result.Headers))
+		for name, values := range result.Headers {
+			snapshot.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	if fetchErr != nil {
+		snapshot.Error = fetchErr.Error()
+	}
+
+	if _, err := database.GetSnapshotsCollection().InsertOne(ctx, snapshot); err != nil {
+		log.Printf("[scheduler] Failed to store snapshot for monitor %s: %v", m.ID.Hex(), err)
+		return
+	}
+	if err := PruneSnapshots(ctx, m.ID); err != nil {
+		log.Printf("[scheduler] Failed to prune snapshots of monitor %s: %v", m.ID.Hex(), err)
+	}
+}
+
+// PruneSnapshots deletes all but the newest maxSnapshots snapshots of a
+// monitor
+func PruneSnapshots(ctx context.Context, monitorID primitive.ObjectID) error {
+	snapshots := database.GetSnapshotsCollection()
+
+	var oldest models.Snapshot
+	err := snapshots.FindOne(ctx,
+		bson.M{"monitorId": monitorID},
+		options.FindOne().
+			SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(maxSnapshots-1).
+			SetProjection(bson.M{"timestamp": 1}),
+	).Decode(&oldest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = snapshots.DeleteMany(ctx, bson.M{
+		"monitorId": monitorID,
+		"$or": bson.A{
+			bson.M{"timestamp": bson.M{"$lt": oldest.Timestamp}},
+			bson.M{"timestamp": oldest.Timestamp, "_id": bson.M{"$lt": oldest.ID}},
+		},
+	})
+	return err
+}
+
+// PreviousSnapshot returns the newest successful snapshot of a monitor
+// taken before the given unix timestamp
+func PreviousSnapshot(ctx context.Context, monitorID primitive.ObjectID, before int64) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	err := database.GetSnapshotsCollection().FindOne(ctx,
+		bson.M{
+			"monitorId": monitorID,
+			"timestamp": bson.M{"$lt": before},
+			"error":     bson.M{"$exists": false},
+		},
+		options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}}),
+	).Decode(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// createAlert stores an alert for a change found by the native scheduler.
+// The payload mirrors the fields changedetection.io sends to the webhook;
+// the typed details carry a line diff against the previous snapshot.
+func createAlert(ctx context.Context, m models.Monitor, result *FetchResult, now time.Time) {
+	payload, err := bson.Marshal(bson.M{
+		"source":        BackendNative,
+		"watch_url":     m.URL,
+		"title":         m.WebsiteName,
+		"previous_hash": m.ContentHash,
+		"current_hash":  result.Hash,
+		"snapshot_ts":   now.Unix(),
+		"checked_at":    now,
+	})
+	if err != nil {
+		log.Printf("[scheduler] Failed to encode alert payload: %v", err)
+		return
+	}
+
+	details := models.AlertDetails{
+		Source:            BackendNative,
+		WatchURL:          m.URL,
+		Title:             m.WebsiteName,
+		Summary:           "Content changed",
+		SnapshotTimestamp: now.Unix(),
+	}
+	if previous, err := PreviousSnapshot(ctx, m.ID, now.Unix()); err == nil {
+		ops := diff.Lines(previous.Content, result.Text)
+		for _, op := range ops {
+			switch op.Kind {
+			case diff.Insert:
+				details.Added = append(details.Added, op.Text)
+			case diff.Delete:
+				details.Removed = append(details.Removed, op.Text)
+			}
+		}
+		details.Summary = diff.CountLines(ops).String()
+		details.Diff = diff.Unified(ops,
+			fmt.Sprintf("snapshot %d", previous.Timestamp),
+			fmt.Sprintf("snapshot %d", now.Unix()),
+			diff.DefaultContext)
+		details.PreviousSnapshotTimestamp = previous.Timestamp
+	} else {
+		log.Printf("[scheduler] No previous snapshot to diff for monitor %s: %v", m.ID.Hex(), err)
+	}
+
+	alert := &models.Alert{
+		ID:           primitive.NewObjectID(),
+		UserID:       m.UserID,
+		MonitorID:    m.ID,
+		Checked:      false,
+		ReceivedAt:   now,
+		AlertDetails: details,
+		Payload:      bson.Raw(payload),
+	}
+
+	key := fmt.Sprintf("%s:%s:%d", BackendNative, m.ID.Hex(), now.Unix())
+	alert, outcome, err := alerting.Store(ctx, m, alert, key)
+	if err != nil {
+		log.Printf("[scheduler] Failed to insert alert: %v", err)
+		return
+	}
+
+	switch outcome {
+	case alerting.Created:
+		log.Printf("[scheduler] Alert created for user %s, monitor %s", m.UserID, m.ID.Hex())
+		notifier.Dispatch(ctx, *alert, m)
+	case alerting.Collapsed:
+		log.Printf("[scheduler] Alert %s for monitor %s now covers %d changes", alert.ID.Hex(), m.ID.Hex(), alert.Count)
+	}
+}
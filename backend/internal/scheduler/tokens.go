@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"justping/backend/internal/auth"
+	"justping/backend/internal/database"
+	"justping/backend/internal/models"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BackfillWebhookTokens gives each changedetection.io monitor created before
+// webhook tokens a token and points its watch at the tokenized webhook URL.
+// Monitors that fail are left as they were and retried on the next start.
+// Nothing is done while PUBLIC_BASE_URL is unset, since the watches could not
+// be given the new URL.
+func BackfillWebhookTokens() {
+	if os.Getenv("PUBLIC_BASE_URL") == "" {
+		return
+	}
+
+	collection := database.GetMonitorsCollection()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"changeDetectionUuid": bson.M{"$nin": bson.A{nil, ""}},
+		"webhookToken":        bson.M{"$in": bson.A{nil, ""}},
+	})
+	if err != nil {
+		log.Printf("[scheduler] Database error: %v", err)
+		return
+	}
+	var monitors []models.Monitor
+	if err := cursor.All(ctx, &monitors); err != nil {
+		log.Printf("[scheduler] Cursor error: %v", err)
+		return
+	}
+	if len(monitors) == 0 {
+		return
+	}
+
+	backend := newChangeDetectionBackend()
+	done := 0
+	for _, legacy := range monitors {
+		token, err := auth.NewWebhookToken()
+		if err != nil {
+			log.Printf("[scheduler] Webhook token error: %v", err)
+			return
+		}
+		updated := legacy
+		updated.WebhookToken = token
+
+		// Point the watch at the new URL first; until the token is stored
+		// its deliveries are rejected, so on failure the watch is put back
+		// in line with whatever the monitor now holds
+		if err := backend.Update(&updated); err != nil {
+			log.Printf("[scheduler] Failed to set webhook URL of watch %s: %v", legacy.ChangeDetectionUUID, err)
+			continue
+		}
+		res, err := collection.UpdateOne(ctx,
+			bson.M{"_id": legacy.ID, "webhookToken": bson.M{"$in": bson.A{nil, ""}}},
+			bson.M{"$set": bson.M{"webhookToken": token}},
+		)
+		if err != nil || res.MatchedCount == 0 {
+			log.Printf("[scheduler] Failed to store webhook token of monitor %s: %v", legacy.ID.Hex(), err)
+			var current models.Monitor
+			if err := collection.FindOne(ctx, bson.M{"_id": legacy.ID}).Decode(&current); err == nil {
+				if err := backend.Update(&current); err != nil {
+					log.Printf("[scheduler] Rollback of watch %s failed: %v", legacy.ChangeDetectionUUID, err)
+				}
+			}
+			continue
+		}
+		done++
+	}
+
+	log.Printf("[scheduler] Gave %d of %d legacy monitors a webhook token", done, len(monitors))
+}
@@ -7,19 +7,69 @@ export interface Alert {
   monitorName?: string;
   checked: boolean;
   receivedAt: string;
+  source?: 'changedetection' | 'native';
+  watchUuid?: string;
+  watchUrl?: string;
+  title?: string;
+  summary?: string;
+  diff?: string;
+  added?: string[];
+  removed?: string[];
+  triggeredText?: string;
+  previewUrl?: string;
+  diffUrl?: string;
+  snapshotTs?: number;
+  previousSnapshotTs?: number;
+  count?: number;
+  lastChangeAt?: string;
+  lastSnapshotTs?: number;
+  escalationLevel?: number; // Escalation steps already notified
   payload: Record<string, unknown>;
 }
 
+export interface AlertPage {
+  alerts: Alert[];
+  nextCursor?: string;
+  total: number;
+  unread: number;
+}
+
+export interface AlertQuery {
+  limit?: number;
+  cursor?: string;
+  monitorId?: string;
+  checked?: boolean;
+  archived?: boolean;
+  from?: string;
+  to?: string;
+}
+
+export type AlertAction = 'check' | 'uncheck' | 'archive' | 'unarchive' | 'delete';
+
+export interface BulkAlertRequest {
+  action: AlertAction;
+  ids?: string[];
+  filter?: Omit<AlertQuery, 'limit' | 'cursor'>;
+}
+
 export interface MarkCheckedResponse {
   status: string;
   markedCount: number;
 }
 
 /**
- * Fetch all alerts for the logged-in user, sorted by receivedAt DESC
+ * Fetch one page of alerts for the logged-in user, sorted by receivedAt DESC
  */
-export async function fetchAlerts(): Promise<Alert[]> {
-  const response = await fetch(`${API_BASE_URL}/api/alerts`, {
+export async function fetchAlertPage(query: AlertQuery = {}): Promise<AlertPage> {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value !== undefined && value !== '') {
+      params.set(key, String(value));
+    }
+  }
+  const qs = params.toString();
+
+  const response = await fetch(`${API_BASE_URL}/api/alerts${qs ? `?${qs}` : ''}`, {
     method: 'GET',
     credentials: 'include', // Send cookies for auth
     headers: {
@@ -58,3 +108,74 @@ export async function markAlertsAsChecked(): Promise<MarkCheckedResponse> {
 
   return response.json();
 }
+
+/**
+ * Apply an action to a single alert
+ */
+export async function updateAlert(id: string, action: AlertAction): Promise<void> {
+  const url = action === 'delete'
+    ? `${API_BASE_URL}/api/alerts/${id}`
+    : `${API_BASE_URL}/api/alerts/${id}/${action}`;
+
+  const response = await fetch(url, {
+    method: action === 'delete' ? 'DELETE' : 'POST',
+    credentials: 'include', // Send cookies for auth
+    headers: {
+      'Content-Type': 'application/json',
+    },
+  });
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Unauthorized - please log in');
+    }
+    throw new Error(`Failed to ${action} alert: ${response.statusText}`);
+  }
+}
+
+/**
+ * Apply an action to a list of alerts or to every alert matching a filter
+ */
+export async function bulkUpdateAlerts(request: BulkAlertRequest): Promise<{ count: number }> {
+  const response = await fetch(`${API_BASE_URL}/api/alerts/bulk`, {
+    method: 'POST',
+    credentials: 'include', // Send cookies for auth
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify(request),
+  });
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      throw new Error('Unauthorized - please log in');
+    }
+    throw new Error(`Failed to ${request.action} alerts: ${response.statusText}`);
+  }
+
+  return response.json();
+}
+
+/**
+ * Listen for alerts as they are stored. The browser reconnects on its own and
+ * resumes from the last event it saw. Returns a function that closes the stream.
+ */
+export function subscribeAlerts(
+  onAlert: (alert: Alert, type: 'alert' | 'alert-update') => void,
+): () => void {
+  const source = new EventSource(`${API_BASE_URL}/api/alerts/stream`, {
+    withCredentials: true, // Send cookies for auth
+  });
+
+  const handle = (type: 'alert' | 'alert-update') => (event: MessageEvent) => {
+    try {
+      onAlert(JSON.parse(event.data) as Alert, type);
+    } catch (err) {
+      console.error('Failed to parse alert event', err);
+    }
+  };
+  source.addEventListener('alert', handle('alert'));
+  source.addEventListener('alert-update', handle('alert-update'));
+
+  return () => source.close();
+}
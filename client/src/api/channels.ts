@@ -0,0 +1,177 @@
+// API client for notification channel operations
+const API_BASE_URL = `${import.meta.env.VITE_API_BASE_URL || 'http://localhost:3002'}/api`
+
+export type ChannelType = 'slack' | 'discord' | 'telegram' | 'webhook'
+
+export interface Channel {
+  _id: string
+  userId: string
+  type: ChannelType
+  name: string
+  webhookUrl?: string // Masked by the server
+  enabled: boolean
+  secret?: string // Webhook signing secret, only returned on create and rotate
+  chatId?: number // Telegram chat, set once linked
+  createdAt: string
+  updatedAt: string
+}
+
+export type DeliveryStatus = 'deferred' | 'pending' | 'sending' | 'sent' | 'failed' | 'dead'
+
+export interface DeliveryAttempt {
+  at: string
+  durationMs: number
+  error?: string
+  statusCode?: number
+  responseBody?: string
+}
+
+export interface Delivery {
+  _id: string
+  alertId: string
+  monitorId: string
+  userId: string
+  channel: string
+  channelId?: string
+  status: DeliveryStatus
+  attempts: DeliveryAttempt[]
+  failures: number
+  nextAttemptAt?: string
+  escalation?: number // Escalation step that sent it
+  createdAt: string
+  updatedAt: string
+  sentAt?: string
+}
+
+export interface DeliveryQuery {
+  status?: DeliveryStatus
+  channel?: string
+  channelId?: string
+  limit?: number
+}
+
+export interface TelegramLink {
+  channelId: string
+  code: string
+  link?: string // t.me deep link, when the bot username is configured
+  expiresAt: string
+}
+
+export interface ChannelData {
+  type?: ChannelType
+  name?: string
+  webhookUrl?: string
+  enabled?: boolean
+}
+
+// Helper to make authenticated requests
+async function fetchWithAuth(url: string, options: RequestInit = {}) {
+  const response = await fetch(url, {
+    ...options,
+    credentials: 'include', // Send cookies with request
+    headers: {
+      'Content-Type': 'application/json',
+      ...options.headers,
+    },
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      window.location.href = '/login'
+      throw new Error('Unauthorized')
+    }
+    const text = await response.text()
+    let message = text.trim() || 'Request failed'
+    try {
+      message = JSON.parse(text).error || message
+    } catch {
+      // Plain-text error
+    }
+    throw new Error(message)
+  }
+
+  return response
+}
+
+export const channelApi = {
+  // List the current user's channels
+  async getChannels(): Promise<Channel[]> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/channels`)
+    return response.json()
+  },
+
+  // Add a Slack or Discord incoming webhook, or an outbound webhook endpoint
+  async createChannel(data: ChannelData & { type: ChannelType; webhookUrl: string }): Promise<Channel> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/channels`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    })
+    return response.json()
+  },
+
+  // Update name, webhook URL or enabled
+  async updateChannel(id: string, data: ChannelData): Promise<Channel> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/channels/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    })
+    return response.json()
+  },
+
+  // Delete channel
+  async deleteChannel(id: string): Promise<void> {
+    await fetchWithAuth(`${API_BASE_URL}/channels/${id}`, {
+      method: 'DELETE',
+    })
+  },
+
+  // Start linking a Telegram chat; send /start <code> to the bot to finish
+  async linkTelegram(): Promise<TelegramLink> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/channels/telegram/link`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  // Replace a webhook's signing secret; the response carries the new one
+  async rotateSecret(id: string): Promise<Channel> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/channels/${id}/secret`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  // Delivery log of one channel, with every attempt
+  async getChannelDeliveries(id: string, status?: DeliveryStatus): Promise<Delivery[]> {
+    const params = status ? `?status=${status}` : ''
+    const response = await fetchWithAuth(`${API_BASE_URL}/channels/${id}/deliveries${params}`)
+    return response.json()
+  },
+
+  // Deliveries across channels; status 'dead' is the dead-letter list
+  async getDeliveries(query: DeliveryQuery = {}): Promise<Delivery[]> {
+    const params = new URLSearchParams()
+    if (query.status) params.set('status', query.status)
+    if (query.channel) params.set('channel', query.channel)
+    if (query.channelId) params.set('channelId', query.channelId)
+    if (query.limit) params.set('limit', String(query.limit))
+    const qs = params.toString()
+    const response = await fetchWithAuth(`${API_BASE_URL}/deliveries${qs ? `?${qs}` : ''}`)
+    return response.json()
+  },
+
+  // Send a failed or dead delivery again
+  async retryDelivery(id: string): Promise<Delivery> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/deliveries/${id}/retry`, {
+      method: 'POST',
+    })
+    return response.json()
+  },
+
+  // Send a sample alert to the channel
+  async testChannel(id: string): Promise<void> {
+    await fetchWithAuth(`${API_BASE_URL}/channels/${id}/test`, {
+      method: 'POST',
+    })
+  },
+}
@@ -0,0 +1,81 @@
+// API client for escalation policies
+const API_BASE_URL = `${import.meta.env.VITE_API_BASE_URL || 'http://localhost:3002'}/api`
+
+// Notifies channel once an alert has gone unchecked for afterMinutes
+export interface EscalationStep {
+  afterMinutes: number // Since the alert was first notified; increases step to step
+  channel: string // Notifier name, e.g. 'email' or 'slack'
+  channelId?: string // One of the user's channels; unset sends to all of that type
+}
+
+// The monitor's own notification is the first step; checking the alert
+// stops the chain
+export interface EscalationPolicy {
+  _id: string
+  userId: string
+  name: string
+  steps: EscalationStep[]
+  createdAt: string
+  updatedAt: string
+}
+
+export interface EscalationPolicyData {
+  name?: string
+  steps?: EscalationStep[]
+}
+
+// Helper to make authenticated requests
+async function fetchWithAuth(url: string, options: RequestInit = {}) {
+  const response = await fetch(url, {
+    ...options,
+    credentials: 'include', // Send cookies with request
+    headers: {
+      'Content-Type': 'application/json',
+      ...options.headers,
+    },
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      window.location.href = '/login'
+      throw new Error('Unauthorized')
+    }
+    const text = await response.text()
+    throw new Error(text.trim() || 'Request failed')
+  }
+
+  return response
+}
+
+export const escalationApi = {
+  // List the current user's escalation policies
+  async getPolicies(): Promise<EscalationPolicy[]> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/escalation-policies`)
+    return response.json()
+  },
+
+  // Create a policy
+  async createPolicy(data: Required<EscalationPolicyData>): Promise<EscalationPolicy> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/escalation-policies`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    })
+    return response.json()
+  },
+
+  // Update name or steps; omitted fields are unchanged
+  async updatePolicy(id: string, data: EscalationPolicyData): Promise<EscalationPolicy> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/escalation-policies/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    })
+    return response.json()
+  },
+
+  // Delete a policy; monitors using it stop escalating
+  async deletePolicy(id: string): Promise<void> {
+    await fetchWithAuth(`${API_BASE_URL}/escalation-policies/${id}`, {
+      method: 'DELETE',
+    })
+  },
+}
@@ -15,19 +15,41 @@ export interface Monitor {
     unit: 'minutes' | 'hours'
   }
   hasChanged: boolean
+  digestFrequency?: DigestFrequency // Unset follows the user's settings
+  schedule?: NotificationSchedule // Unset follows the user's schedule
+  escalationPolicyId?: string
   createdAt: string
   updatedAt: string
 }
 
+export type DigestFrequency = 'immediate' | 'daily' | 'weekly'
+
+// When alerts may be sent; alerts in quiet hours wait for the next window
+export interface NotificationSchedule {
+  mode: 'always' | 'windows' | '' // '' on a monitor follows the user's schedule
+  timezone?: string // IANA name; defaults to the settings timezone
+  windows?: ScheduleWindow[]
+  quietAction?: 'defer' | 'digest' // Send each alert later, or one summary
+}
+
+export interface ScheduleWindow {
+  days?: number[] // 0 is Sunday; empty means every day
+  start: string // Local 'HH:MM'
+  end: string // At or before start ends the next day
+}
+
 export interface CreateMonitorData {
   websiteName: string
   targetType: string
   url: string
-  selector?: string
+  selector?: string // '' removes the selector
   frequency?: {
     value: number
     unit: 'minutes' | 'hours'
   }
+  digestFrequency?: DigestFrequency | '' // '' follows the user's settings
+  schedule?: NotificationSchedule // Mode '' follows the user's schedule
+  escalationPolicyId?: string // '' removes the policy
 }
 
 // Helper to make authenticated requests
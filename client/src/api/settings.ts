@@ -0,0 +1,58 @@
+// API client for user settings
+import type { DigestFrequency, NotificationSchedule } from './monitors'
+
+const API_BASE_URL = `${import.meta.env.VITE_API_BASE_URL || 'http://localhost:3002'}/api`
+
+export interface NotificationSettings {
+  userId: string
+  timezone: string // IANA name, e.g. 'Europe/Berlin'
+  digestFrequency: DigestFrequency
+  digestTime: string // Local 'HH:MM'
+  digestWeekday: number // 0 is Sunday
+  schedule?: NotificationSchedule
+  updatedAt: string
+}
+
+export type NotificationSettingsData = Partial<
+  Pick<NotificationSettings, 'timezone' | 'digestFrequency' | 'digestTime' | 'digestWeekday' | 'schedule'>
+>
+
+// Helper to make authenticated requests
+async function fetchWithAuth(url: string, options: RequestInit = {}) {
+  const response = await fetch(url, {
+    ...options,
+    credentials: 'include', // Send cookies with request
+    headers: {
+      'Content-Type': 'application/json',
+      ...options.headers,
+    },
+  })
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      window.location.href = '/login'
+      throw new Error('Unauthorized')
+    }
+    const text = await response.text()
+    throw new Error(text.trim() || 'Request failed')
+  }
+
+  return response
+}
+
+export const settingsApi = {
+  // Get the current user's notification settings
+  async getNotificationSettings(): Promise<NotificationSettings> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/settings/notifications`)
+    return response.json()
+  },
+
+  // Update timezone and digest schedule; omitted fields are unchanged
+  async updateNotificationSettings(data: NotificationSettingsData): Promise<NotificationSettings> {
+    const response = await fetchWithAuth(`${API_BASE_URL}/settings/notifications`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    })
+    return response.json()
+  },
+}
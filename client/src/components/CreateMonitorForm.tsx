@@ -1,4 +1,4 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { Button } from './ui/button';
 import { Label } from './ui/label';
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from './ui/select';
@@ -6,6 +6,8 @@ import { Switch } from './ui/switch';
 import { Collapsible, CollapsibleContent, CollapsibleTrigger } from './ui/collapsible';
 import { Input } from './ui/input';
 import { ChevronDown, Calendar } from 'lucide-react';
+import { channelApi, type ChannelType } from '@/api/channels';
+import { useDemo } from '@/context/DemoContext';
 
 interface CreateMonitorFormProps {
   selector: string;
@@ -40,6 +42,14 @@ const FREQUENCY_OPTIONS = [
   { label: '24 hours', value: 24, unit: 'hours' },
 ];
 
+// Methods that need a channel set up on the Integrations page first
+const CHANNEL_METHODS: { value: ChannelType; label: string }[] = [
+  { value: 'webhook', label: 'Webhook' },
+  { value: 'slack', label: 'Slack' },
+  { value: 'discord', label: 'Discord' },
+  { value: 'telegram', label: 'Telegram' },
+];
+
 const DURATION_OPTIONS = [
   { label: 'Run forever', value: 'forever' },
   { label: 'Stop on specific date', value: 'until_date' },
@@ -63,6 +73,17 @@ export default function CreateMonitorForm({
   const [notificationMethod, setNotificationMethod] = useState('email');
   const [detectionMode, setDetectionMode] = useState('text');
   const [showAdvanced, setShowAdvanced] = useState(false);
+  const [configuredChannels, setConfiguredChannels] = useState<Set<string>>(new Set());
+  const { isDemoMode } = useDemo();
+
+  // Only offer channel types the user has an enabled channel for
+  useEffect(() => {
+    if (isDemoMode) return;
+    channelApi
+      .getChannels()
+      .then((channels) => setConfiguredChannels(new Set(channels.filter((c) => c.enabled).map((c) => c.type))))
+      .catch(console.error);
+  }, [isDemoMode]);
 
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault();
@@ -210,12 +231,15 @@ export default function CreateMonitorForm({
                   </SelectTrigger>
                   <SelectContent>
                     <SelectItem value="email">Email</SelectItem>
-                    <SelectItem value="webhook" disabled>
-                      Webhook (Coming soon)
-                    </SelectItem>
-                    <SelectItem value="slack" disabled>
-                      Slack (Coming soon)
-                    </SelectItem>
+                    {CHANNEL_METHODS.map((method) => (
+                      <SelectItem
+                        key={method.value}
+                        value={method.value}
+                        disabled={!configuredChannels.has(method.value)}
+                      >
+                        {configuredChannels.has(method.value) ? method.label : `${method.label} (set up in Integrations)`}
+                      </SelectItem>
+                    ))}
                   </SelectContent>
                 </Select>
               </div>
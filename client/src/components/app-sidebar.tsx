@@ -11,7 +11,7 @@ import {
 } from "lucide-react"
 import { Link, useNavigate, useLocation } from "react-router-dom"
 import { authClient } from "@/lib/auth-client"
-import { fetchAlerts, type Alert } from "@/api/alerts"
+import { fetchAlertPage, subscribeAlerts, type Alert } from "@/api/alerts"
 import { useDemo } from "@/context/DemoContext"
 
 import {
@@ -52,13 +52,13 @@ export function AppSidebar() {
     useEffect(() => {
         const checkAlerts = async () => {
             try {
-                let currentAlerts: Alert[] = [];
                 if (isDemoMode) {
-                    currentAlerts = demoAlerts;
+                    setHasUnreadAlerts(demoAlerts.some((a: Alert) => !a.checked));
                 } else {
-                    currentAlerts = await fetchAlerts();
+                    // unread counts every alert, not just the first page
+                    const page = await fetchAlertPage({ limit: 1 });
+                    setHasUnreadAlerts(page.unread > 0);
                 }
-                setHasUnreadAlerts(currentAlerts.some((a: Alert) => !a.checked));
             } catch (err) {
                 console.error("Failed to fetch alerts for sidebar indicator", err);
             }
@@ -67,6 +67,14 @@ export function AppSidebar() {
         checkAlerts();
     }, [isDemoMode, demoAlerts, pathname]);
 
+    // New alerts light up the indicator without waiting for a navigation
+    useEffect(() => {
+        if (isDemoMode) return;
+        return subscribeAlerts((alert) => {
+            if (!alert.checked) setHasUnreadAlerts(true);
+        });
+    }, [isDemoMode]);
+
     const handleSignOut = async () => {
         await authClient.signOut()
         navigate('/')
@@ -1,14 +1,18 @@
 import { useEffect, useState } from "react";
 import { Card } from "@/components/ui/card";
 import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
 import { ScrollArea } from "@/components/ui/scroll-area";
 import { AlertCircle, Bell, CheckCircle2, Loader2 } from "lucide-react";
-import { fetchAlerts, markAlertsAsChecked, type Alert } from "@/api/alerts";
+import { fetchAlertPage, markAlertsAsChecked, type Alert } from "@/api/alerts";
 import { useDemo } from "@/context/DemoContext";
 
 export default function Alerts() {
     const [alerts, setAlerts] = useState<Alert[]>([]);
+    const [total, setTotal] = useState(0);
+    const [nextCursor, setNextCursor] = useState<string | undefined>();
     const [loading, setLoading] = useState(true);
+    const [loadingMore, setLoadingMore] = useState(false);
     const [error, setError] = useState<string | null>(null);
     const { isDemoMode, demoAlerts } = useDemo();
 
@@ -21,16 +25,20 @@ export default function Alerts() {
                     // Simulate network delay
                     await new Promise(resolve => setTimeout(resolve, 600));
                     setAlerts(demoAlerts);
+                    setTotal(demoAlerts.length);
+                    setNextCursor(undefined);
                     setLoading(false);
                     return;
                 }
 
-                // Fetch alerts
-                const data = await fetchAlerts();
-                setAlerts(data);
+                // Fetch the first page; older alerts load on demand
+                const page = await fetchAlertPage();
+                setAlerts(page.alerts);
+                setTotal(page.total);
+                setNextCursor(page.nextCursor);
                 
                 // Mark as checked when page opens (fire and forget)
-                if (data.some(a => !a.checked)) {
+                if (page.unread > 0) {
                     markAlertsAsChecked().catch(console.error);
                 }
             } catch (err) {
@@ -43,6 +51,21 @@ export default function Alerts() {
         loadAlerts();
     }, [isDemoMode]);
 
+    const loadMore = async () => {
+        if (!nextCursor) return;
+        try {
+            setLoadingMore(true);
+            const page = await fetchAlertPage({ cursor: nextCursor });
+            setAlerts(prev => [...prev, ...page.alerts]);
+            setTotal(page.total);
+            setNextCursor(page.nextCursor);
+        } catch (err) {
+            setError(err instanceof Error ? err.message : "Failed to load alerts");
+        } finally {
+            setLoadingMore(false);
+        }
+    };
+
     // Format relative time
     const formatRelativeTime = (dateStr: string) => {
         const date = new Date(dateStr);
@@ -61,6 +84,9 @@ export default function Alerts() {
 
     // Get alert title from payload
     const getAlertTitle = (alert: Alert) => {
+        if (alert.title && alert.count && alert.count > 1) return `${alert.title} (${alert.count} changes)`;
+        if (alert.title) return alert.title;
+        if (alert.watchUrl) return `Change on ${alert.watchUrl}`;
         const payload = alert.payload;
         if (payload.watch_title) return String(payload.watch_title);
         if (payload.watch_url) return `Change on ${String(payload.watch_url)}`;
@@ -69,6 +95,10 @@ export default function Alerts() {
 
     // Get alert message from payload
     const getAlertMessage = (alert: Alert) => {
+        if (alert.summary) {
+            const added = alert.added?.slice(0, 3).join(" · ");
+            return added ? `${alert.summary}: ${added}` : `Content changed: ${alert.summary}`;
+        }
         const payload = alert.payload;
         if (payload.diff) return `Content changed: ${String(payload.diff).slice(0, 150)}...`;
         if (payload.current_snapshot) {
@@ -105,7 +135,7 @@ export default function Alerts() {
                     </p>
                 </div>
                 <Badge variant="outline" className="px-3 py-1">
-                    {alerts.length} total
+                    {total} total
                 </Badge>
             </div>
 
@@ -166,9 +196,18 @@ export default function Alerts() {
                             </Card>
                         ))}
 
-                        <div className="text-center py-8 text-muted-foreground text-sm">
-                            End of notifications
-                        </div>
+                        {nextCursor ? (
+                            <div className="flex justify-center py-4">
+                                <Button variant="outline" onClick={loadMore} disabled={loadingMore}>
+                                    {loadingMore && <Loader2 className="h-4 w-4 mr-2 animate-spin" />}
+                                    Load more
+                                </Button>
+                            </div>
+                        ) : (
+                            <div className="text-center py-8 text-muted-foreground text-sm">
+                                End of notifications
+                            </div>
+                        )}
                     </div>
                 </ScrollArea>
             )}
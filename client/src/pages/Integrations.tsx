@@ -2,98 +2,183 @@ import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/com
 import { Button } from "@/components/ui/button";
 import { Badge } from "@/components/ui/badge";
 import { Switch } from "@/components/ui/switch";
-import { useState } from "react";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { AlertCircle, Loader2 } from "lucide-react";
+import { useEffect, useState } from "react";
+import { channelApi, type Channel, type ChannelType, type TelegramLink } from "@/api/channels";
+import { useDemo } from "@/context/DemoContext";
 
-interface Integration {
-    id: string;
+interface ChannelKind {
+    type: ChannelType;
     name: string;
     description: string;
     icon: string;
-    connected: boolean;
-    category: "notifications" | "automation" | "storage";
+    urlLabel?: string; // Channels added with a URL; Telegram is linked instead
+    urlPlaceholder?: string;
 }
 
-const integrations: Integration[] = [
+const channelKinds: ChannelKind[] = [
     {
-        id: "slack",
+        type: "slack",
         name: "Slack",
         description: "Get instant alerts in your Slack channels",
         icon: "💬",
-        connected: true,
-        category: "notifications",
+        urlLabel: "Incoming webhook URL",
+        urlPlaceholder: "https://hooks.slack.com/services/...",
     },
     {
-        id: "discord",
+        type: "discord",
         name: "Discord",
         description: "Send notifications to Discord servers",
         icon: "🎮",
-        connected: false,
-        category: "notifications",
+        urlLabel: "Webhook URL",
+        urlPlaceholder: "https://discord.com/api/webhooks/...",
     },
     {
-        id: "email",
-        name: "Email",
-        description: "Receive alerts via email",
-        icon: "📧",
-        connected: true,
-        category: "notifications",
-    },
-    {
-        id: "telegram",
+        type: "telegram",
         name: "Telegram",
         description: "Get notified through Telegram bot",
         icon: "✈️",
-        connected: false,
-        category: "notifications",
     },
     {
-        id: "webhook",
+        type: "webhook",
         name: "Webhooks",
-        description: "Send data to any HTTP endpoint",
+        description: "Send signed JSON to any HTTPS endpoint",
         icon: "🔗",
-        connected: true,
-        category: "automation",
-    },
-    {
-        id: "zapier",
-        name: "Zapier",
-        description: "Connect to 5000+ apps via Zapier",
-        icon: "⚡",
-        connected: false,
-        category: "automation",
-    },
-    {
-        id: "google-sheets",
-        name: "Google Sheets",
-        description: "Log changes to a spreadsheet",
-        icon: "📊",
-        connected: false,
-        category: "storage",
-    },
-    {
-        id: "notion",
-        name: "Notion",
-        description: "Save changes to Notion databases",
-        icon: "📝",
-        connected: false,
-        category: "storage",
+        urlLabel: "Endpoint URL",
+        urlPlaceholder: "https://example.com/hooks/justping",
     },
 ];
 
+// Not available yet
+const upcoming = [
+    { id: "zapier", name: "Zapier", description: "Connect to 5000+ apps via Zapier", icon: "⚡" },
+    { id: "google-sheets", name: "Google Sheets", description: "Log changes to a spreadsheet", icon: "📊" },
+    { id: "notion", name: "Notion", description: "Save changes to Notion databases", icon: "📝" },
+];
+
 export default function Integrations() {
-    const [connectedStates, setConnectedStates] = useState<Record<string, boolean>>(
-        Object.fromEntries(integrations.map(i => [i.id, i.connected]))
-    );
+    const { isDemoMode } = useDemo();
+    const [channels, setChannels] = useState<Channel[]>([]);
+    const [loading, setLoading] = useState(true);
+    const [error, setError] = useState<string | null>(null);
+    const [notice, setNotice] = useState<string | null>(null);
+
+    // Add form for one channel type at a time
+    const [adding, setAdding] = useState<ChannelType | null>(null);
+    const [name, setName] = useState("");
+    const [webhookUrl, setWebhookUrl] = useState("");
+    const [saving, setSaving] = useState(false);
 
-    const toggleConnection = (id: string) => {
-        setConnectedStates(prev => ({ ...prev, [id]: !prev[id] }));
+    const [telegramLink, setTelegramLink] = useState<TelegramLink | null>(null);
+    const [secret, setSecret] = useState<{ channelId: string; secret: string } | null>(null);
+    const [busy, setBusy] = useState<string | null>(null); // Channel with a pending action
+
+    const loadChannels = async () => {
+        if (isDemoMode) {
+            setChannels([]);
+            setLoading(false);
+            return;
+        }
+        try {
+            setLoading(true);
+            setChannels(await channelApi.getChannels());
+            setError(null);
+        } catch (err) {
+            setError(err instanceof Error ? err.message : "Failed to load channels");
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    useEffect(() => {
+        loadChannels();
+    }, [isDemoMode]);
+
+    // Runs an action on one channel, reporting failures in the notice
+    const withChannel = async (id: string, action: () => Promise<void>) => {
+        setBusy(id);
+        setNotice(null);
+        try {
+            await action();
+        } catch (err) {
+            setNotice(err instanceof Error ? err.message : "Request failed");
+        } finally {
+            setBusy(null);
+        }
+    };
+
+    const startAdding = (type: ChannelType) => {
+        setAdding(type);
+        setName("");
+        setWebhookUrl("");
+        setNotice(null);
     };
 
-    const categories = [
-        { key: "notifications", label: "Notifications" },
-        { key: "automation", label: "Automation" },
-        { key: "storage", label: "Storage" },
-    ] as const;
+    const addChannel = async (e: React.FormEvent) => {
+        e.preventDefault();
+        if (!adding) return;
+        try {
+            setSaving(true);
+            setNotice(null);
+            const channel = await channelApi.createChannel({ type: adding, name, webhookUrl });
+            setChannels(prev => [...prev, channel]);
+            if (channel.secret) {
+                setSecret({ channelId: channel._id, secret: channel.secret });
+            }
+            setAdding(null);
+        } catch (err) {
+            setNotice(err instanceof Error ? err.message : "Failed to add channel");
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const linkTelegram = async () => {
+        try {
+            setNotice(null);
+            setTelegramLink(await channelApi.linkTelegram());
+        } catch (err) {
+            setNotice(err instanceof Error ? err.message : "Failed to start linking");
+        }
+    };
+
+    const setEnabled = (channel: Channel, enabled: boolean) =>
+        withChannel(channel._id, async () => {
+            const updated = await channelApi.updateChannel(channel._id, { enabled });
+            setChannels(prev => prev.map(c => (c._id === channel._id ? updated : c)));
+        });
+
+    const testChannel = (channel: Channel) =>
+        withChannel(channel._id, async () => {
+            await channelApi.testChannel(channel._id);
+            setNotice(`Sent a test alert to ${channel.name}.`);
+        });
+
+    const rotateSecret = (channel: Channel) =>
+        withChannel(channel._id, async () => {
+            const updated = await channelApi.rotateSecret(channel._id);
+            if (updated.secret) {
+                setSecret({ channelId: channel._id, secret: updated.secret });
+            }
+        });
+
+    const deleteChannel = (channel: Channel) => {
+        if (!confirm(`Delete ${channel.name}? Monitors stop sending alerts to it.`)) return;
+        return withChannel(channel._id, async () => {
+            await channelApi.deleteChannel(channel._id);
+            setChannels(prev => prev.filter(c => c._id !== channel._id));
+        });
+    };
+
+    if (loading) {
+        return (
+            <div className="h-full flex items-center justify-center">
+                <Loader2 className="h-8 w-8 animate-spin text-muted-foreground" />
+            </div>
+        );
+    }
 
     return (
         <div className="h-full flex flex-col p-4 md:p-6 max-w-5xl mx-auto w-full space-y-6">
@@ -104,46 +189,183 @@ export default function Integrations() {
                 </p>
             </div>
 
-            {categories.map(category => (
-                <div key={category.key} className="space-y-4">
-                    <h3 className="text-lg font-semibold capitalize">{category.label}</h3>
-                    <div className="grid gap-4 md:grid-cols-2">
-                        {integrations
-                            .filter(i => i.category === category.key)
-                            .map(integration => (
-                                <Card key={integration.id} className="hover:bg-secondary/10 transition-colors">
-                                    <CardHeader className="pb-3">
-                                        <div className="flex items-center justify-between">
-                                            <div className="flex items-center gap-3">
-                                                <span className="text-2xl">{integration.icon}</span>
-                                                <div>
-                                                    <CardTitle className="text-base flex items-center gap-2">
-                                                        {integration.name}
-                                                        {connectedStates[integration.id] && (
-                                                            <Badge variant="secondary" className="text-xs">Connected</Badge>
-                                                        )}
-                                                    </CardTitle>
+            {error && (
+                <div className="flex items-center gap-2 text-sm text-destructive">
+                    <AlertCircle className="h-4 w-4" />
+                    {error}
+                </div>
+            )}
+            {notice && <p className="text-sm text-muted-foreground">{notice}</p>}
+            {isDemoMode && (
+                <p className="text-sm text-muted-foreground">Channels can be added once you sign in.</p>
+            )}
+
+            <div className="space-y-4">
+                <h3 className="text-lg font-semibold">Notifications</h3>
+                <div className="grid gap-4 md:grid-cols-2">
+                    <Card>
+                        <CardHeader className="pb-3">
+                            <div className="flex items-center gap-3">
+                                <span className="text-2xl">📧</span>
+                                <CardTitle className="text-base flex items-center gap-2">
+                                    Email
+                                    <Badge variant="secondary" className="text-xs">Connected</Badge>
+                                </CardTitle>
+                            </div>
+                        </CardHeader>
+                        <CardContent className="pt-0">
+                            <CardDescription>Alerts go to your account's email address.</CardDescription>
+                        </CardContent>
+                    </Card>
+
+                    {channelKinds.map(kind => {
+                        const ofKind = channels.filter(c => c.type === kind.type);
+                        return (
+                            <Card key={kind.type}>
+                                <CardHeader className="pb-3">
+                                    <div className="flex items-center justify-between">
+                                        <div className="flex items-center gap-3">
+                                            <span className="text-2xl">{kind.icon}</span>
+                                            <CardTitle className="text-base flex items-center gap-2">
+                                                {kind.name}
+                                                {ofKind.some(c => c.enabled) && (
+                                                    <Badge variant="secondary" className="text-xs">Connected</Badge>
+                                                )}
+                                            </CardTitle>
+                                        </div>
+                                        <Button
+                                            variant="outline"
+                                            size="sm"
+                                            disabled={isDemoMode}
+                                            onClick={() => (kind.urlLabel ? startAdding(kind.type) : linkTelegram())}
+                                        >
+                                            {kind.urlLabel ? "Add" : "Link chat"}
+                                        </Button>
+                                    </div>
+                                </CardHeader>
+                                <CardContent className="pt-0 space-y-3">
+                                    <CardDescription>{kind.description}</CardDescription>
+
+                                    {ofKind.map(channel => (
+                                        <div key={channel._id} className="rounded-md border p-3 space-y-2">
+                                            <div className="flex items-center justify-between gap-2">
+                                                <div className="min-w-0">
+                                                    <div className="font-medium text-sm truncate">{channel.name}</div>
+                                                    {channel.webhookUrl && (
+                                                        <div className="text-xs text-muted-foreground font-mono truncate">
+                                                            {channel.webhookUrl}
+                                                        </div>
+                                                    )}
+                                                    {kind.type === "telegram" && !channel.chatId && (
+                                                        <div className="text-xs text-muted-foreground">Waiting for /start</div>
+                                                    )}
+                                                </div>
+                                                <Switch
+                                                    checked={channel.enabled}
+                                                    disabled={busy === channel._id}
+                                                    onCheckedChange={enabled => setEnabled(channel, enabled)}
+                                                />
+                                            </div>
+                                            {secret?.channelId === channel._id && (
+                                                <div className="text-xs">
+                                                    Signing secret, shown once:{" "}
+                                                    <code className="font-mono break-all">{secret.secret}</code>
                                                 </div>
+                                            )}
+                                            <div className="flex gap-2">
+                                                <Button variant="outline" size="sm" disabled={busy === channel._id} onClick={() => testChannel(channel)}>
+                                                    Test
+                                                </Button>
+                                                {kind.type === "webhook" && (
+                                                    <Button variant="outline" size="sm" disabled={busy === channel._id} onClick={() => rotateSecret(channel)}>
+                                                        Rotate secret
+                                                    </Button>
+                                                )}
+                                                <Button variant="ghost" size="sm" disabled={busy === channel._id} onClick={() => deleteChannel(channel)}>
+                                                    Delete
+                                                </Button>
                                             </div>
-                                            <Switch
-                                                checked={connectedStates[integration.id]}
-                                                onCheckedChange={() => toggleConnection(integration.id)}
-                                            />
                                         </div>
-                                    </CardHeader>
-                                    <CardContent className="pt-0">
-                                        <CardDescription>{integration.description}</CardDescription>
-                                        {connectedStates[integration.id] && (
-                                            <Button variant="outline" size="sm" className="mt-3">
-                                                Configure
+                                    ))}
+
+                                    {kind.type === "telegram" && telegramLink && (
+                                        <div className="rounded-md border p-3 text-sm space-y-1">
+                                            <p>
+                                                Send <code className="font-mono">/start {telegramLink.code}</code> to the bot
+                                                {telegramLink.link && (
+                                                    <>
+                                                        {" "}or open{" "}
+                                                        <a href={telegramLink.link} target="_blank" rel="noreferrer" className="underline">
+                                                            this link
+                                                        </a>
+                                                    </>
+                                                )}
+                                                .
+                                            </p>
+                                            <Button variant="outline" size="sm" onClick={loadChannels}>
+                                                I've sent it
                                             </Button>
-                                        )}
-                                    </CardContent>
-                                </Card>
-                            ))}
-                    </div>
+                                        </div>
+                                    )}
+
+                                    {adding === kind.type && (
+                                        <form onSubmit={addChannel} className="rounded-md border p-3 space-y-3">
+                                            <div className="space-y-1">
+                                                <Label htmlFor={`${kind.type}-name`}>Name</Label>
+                                                <Input
+                                                    id={`${kind.type}-name`}
+                                                    value={name}
+                                                    onChange={e => setName(e.target.value)}
+                                                    placeholder={kind.name}
+                                                />
+                                            </div>
+                                            <div className="space-y-1">
+                                                <Label htmlFor={`${kind.type}-url`}>{kind.urlLabel}</Label>
+                                                <Input
+                                                    id={`${kind.type}-url`}
+                                                    type="url"
+                                                    value={webhookUrl}
+                                                    onChange={e => setWebhookUrl(e.target.value)}
+                                                    placeholder={kind.urlPlaceholder}
+                                                    required
+                                                />
+                                            </div>
+                                            <div className="flex gap-2">
+                                                <Button type="submit" size="sm" disabled={saving}>
+                                                    {saving && <Loader2 className="h-4 w-4 animate-spin" />}
+                                                    Save
+                                                </Button>
+                                                <Button type="button" variant="ghost" size="sm" onClick={() => setAdding(null)}>
+                                                    Cancel
+                                                </Button>
+                                            </div>
+                                        </form>
+                                    )}
+                                </CardContent>
+                            </Card>
+                        );
+                    })}
                 </div>
-            ))}
+            </div>
+
+            <div className="space-y-4">
+                <h3 className="text-lg font-semibold">Coming soon</h3>
+                <div className="grid gap-4 md:grid-cols-2">
+                    {upcoming.map(integration => (
+                        <Card key={integration.id} className="opacity-70">
+                            <CardHeader className="pb-3">
+                                <div className="flex items-center gap-3">
+                                    <span className="text-2xl">{integration.icon}</span>
+                                    <CardTitle className="text-base">{integration.name}</CardTitle>
+                                </div>
+                            </CardHeader>
+                            <CardContent className="pt-0">
+                                <CardDescription>{integration.description}</CardDescription>
+                            </CardContent>
+                        </Card>
+                    ))}
+                </div>
+            </div>
         </div>
     );
 }